@@ -1,8 +1,11 @@
 package test
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"math/rand"
 	"net/http"
 	"os"
@@ -24,51 +27,142 @@ func Test(t *testing.T) {
 }
 
 var podmancli *podman.Client
+var diagnostics *podman.Diagnostics
 var imageName string
 
+// sweepAge is how old a run has to be for its leftovers to be removed. It
+// exceeds the longest run, so concurrent runs on a host are left alone.
+const sweepAge = 2 * time.Hour
+
 var _ = BeforeSuite(func() {
 	var err error
 	podmancli, err = podman.NewEnvClient()
 	Expect(err).NotTo(HaveOccurred())
+	diagnostics = &podman.Diagnostics{
+		Client:  podmancli,
+		Labels:  map[string]string{podman.LabelRunID: podmancli.Labels[podman.LabelRunID]},
+		Dir:     os.Getenv("ARTIFACT_DIR"),
+		Tarball: true,
+	}
 
 	imageName = os.Getenv("IMAGE_NAME")
 	if imageName == "" {
 		imageName = "openshift/jenkins-2-centos7-candidate"
 	}
+	// Volume snapshots only need an image with a filesystem; reuse the one
+	// under test rather than pulling another.
+	podmancli.HelperImage = imageName
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	err = podmancli.Sweep(ctx, &podman.SweepOptions{
+		OlderThan:     sweepAge,
+		ImagePrefixes: []string{"jenkins-test-s2i-", "jenkins-test-derived-"},
+	})
+	if err != nil {
+		fmt.Fprintf(GinkgoWriter, "removing resources of earlier runs: %v\n", err)
+	}
+
+	_, err = podmancli.ImagePull(ctx, imageName, podman.PullMissing)
+	Expect(err).NotTo(HaveOccurred())
+})
+
+var _ = AfterSuite(func() {
+	if podmancli == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+	err := podmancli.Tracker.Cleanup(ctx, podmancli)
+	Expect(err).NotTo(HaveOccurred())
+})
+
+var _ = Describe("Jenkins image (v2)", func() {
+	It("should carry the expected metadata", func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+
+		image, err := podmancli.ImageInspect(ctx, imageName)
+		Expect(err).NotTo(HaveOccurred())
+
+		By("having the OpenShift labels")
+		err = image.VerifyLabels(map[string]string{
+			"io.k8s.description":           "",
+			"io.k8s.display-name":          "Jenkins 2",
+			"io.openshift.tags":            "jenkins,jenkins2,ci",
+			"io.openshift.expose-services": "8080:http",
+			"io.openshift.s2i.scripts-url": "image:///usr/libexec/s2i",
+			"io.jenkins.version":           "",
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		By("running s2i/run through go-init")
+		Expect(image.Entrypoint).To(Equal([]string{"/usr/bin/go-init", "-main", "/usr/libexec/s2i/run"}))
+
+		By("exposing the web and agent ports")
+		Expect(image.ExposedPorts).To(ContainElements("8080/tcp", "50000/tcp"))
+
+		By("running as a non-root user with a JENKINS_HOME volume")
+		Expect(image.User).To(Equal("1001"))
+		Expect(image.Volumes).To(ContainElement("/var/lib/jenkins"))
+	})
 })
 
+// createJobFromFile creates a job from a config.xml in testdata.
+func createJobFromFile(ctx context.Context, j *jenkins.Jenkins, name, filename string) error {
+	config, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer config.Close()
+	return j.CreateJob(ctx, name, config)
+}
+
 var _ = Describe("Jenkins testing (v2)", func() {
 	var j *jenkins.Jenkins
-	var imageNamesToRemove []string
+	var ctx context.Context
+	var cancel context.CancelFunc
 
 	BeforeEach(func() {
 		var err error
+		ctx, cancel = context.WithTimeout(context.Background(), 30*time.Minute)
 		j = jenkins.NewJenkins(podmancli)
-		vcr, err := podmancli.VolumeCreate()
-		j.Volume = vcr.Name
+		vcr, err := podmancli.VolumeCreate(ctx, nil)
 		Expect(err).NotTo(HaveOccurred())
+		j.Volume = vcr.Name
 	})
 
 	AfterEach(func() {
+		cancel()
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+		defer cancel()
+
 		if CurrentGinkgoTestDescription().Failed {
+			By("collecting diagnostics")
+			if path, err := diagnostics.Collect(ctx); err != nil {
+				fmt.Fprintf(GinkgoWriter, "collecting diagnostics: %v\n", err)
+			} else {
+				fmt.Fprintf(GinkgoWriter, "diagnostics bundle: %s\n", path)
+			}
+
 			By("printing container logs")
-			logs, err := podmancli.ContainerLogs(j.ID)
+			err := podmancli.ContainerStreamLogs(ctx, j.ID, nil, GinkgoWriter, GinkgoWriter)
 			Expect(err).NotTo(HaveOccurred())
-			_, err = GinkgoWriter.Write(logs)
-			Expect(err).NotTo(HaveOccurred())
-		}
 
-		_, err := podmancli.ContainerStopAndRemove(j.ID, 60)
-		Expect(err).NotTo(HaveOccurred())
+			// The container may already have exited.
+			if stats, err := podmancli.ContainerStats(ctx, j.ID); err == nil {
+				fmt.Fprintf(GinkgoWriter, "container resource usage: %s\n", stats)
+			}
+		}
 
-		err = podmancli.VolumeRemove(j.Volume)
-		Expect(err).NotTo(HaveOccurred())
+		// The spec may have failed before creating the container, or
+		// after removing it.
+		_, err := podmancli.ContainerStopAndRemove(ctx, j.ID, 60)
+		Expect(podman.IgnoreNotFound(err)).To(Succeed())
 
-		for _, imageName := range imageNamesToRemove {
-			_, errs := podmancli.ImagesRemove([]string{imageName})
-			Expect(len(errs)).To(Equal(0))
-		}
-		imageNamesToRemove = nil
+		err = podmancli.VolumeRemove(ctx, j.Volume)
+		Expect(podman.IgnoreNotFound(err)).To(Succeed())
 	})
 
 	basePlugins := []string{
@@ -92,59 +186,72 @@ var _ = Describe("Jenkins testing (v2)", func() {
 	}
 
 	smokeTest := func(password, invalidpassword string, createJob bool, expectedPlugins, nonExpectedPlugins []string) {
+		By("logging that Jenkins is up")
+		err := podmancli.ContainerWaitForLog(ctx, j.ID, "Jenkins is fully up and running")
+		Expect(err).NotTo(HaveOccurred())
+
+		By("passing its healthcheck")
+		_, err = podmancli.WaitFor(ctx, j.ID, podman.ConditionHealthy)
+		Expect(err).NotTo(HaveOccurred())
+
+		admin := j.WithCredentials("admin", password)
+
 		By("loading plugins correctly")
-		logs, err := podmancli.ContainerLogs(j.ID)
+		logs, err := podmancli.ContainerLogs(ctx, j.ID)
 		Expect(err).NotTo(HaveOccurred())
 		Expect(logs).NotTo(ContainSubstring("Failed Loading plugin"))
 
+		failed, err := admin.FailedPlugins(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(failed).To(BeEmpty())
+
 		By("having the right plugins installed")
-		code, out, err := podmancli.ContainerExec(j.ID, []string{"ls", "/var/lib/jenkins/plugins"})
+		plugins, err := admin.Plugins(ctx)
 		Expect(err).NotTo(HaveOccurred())
-		Expect(code).To(Equal(0))
-		files := strings.Split(string(out), "\n")
+		active := map[string]bool{}
+		for _, plugin := range plugins {
+			active[plugin.ShortName] = plugin.Active
+		}
 
 		for _, elem := range expectedPlugins {
-			Expect(files).To(ContainElement(elem + ".jpi"))
+			Expect(active).To(HaveKeyWithValue(elem, true))
 		}
 		for _, elem := range nonExpectedPlugins {
-			Expect(files).NotTo(ContainElement(elem + ".jpi"))
+			Expect(active).NotTo(HaveKey(elem))
 		}
-
 		if createJob {
 			By("creating a test job")
-			resp, err := j.CreateJob("testJob", password, "testdata/testjob.xml")
+			err := createJobFromFile(ctx, admin, "testJob", "testdata/testjob.xml")
 			Expect(err).NotTo(HaveOccurred())
-			Expect(resp.StatusCode).To(Equal(http.StatusOK))
 		}
 
 		By("checking the test job exists")
-		resp, err := j.GetJob("testJob", password)
+		_, err = admin.GetJob(ctx, "testJob")
 		Expect(err).NotTo(HaveOccurred())
-		Expect(resp.StatusCode).To(Equal(http.StatusOK))
 
 		By("failing to create a test job with an invalid password")
-		resp, err = j.CreateJob("failJob", invalidpassword, "testdata/testjob.xml")
-		Expect(err).NotTo(HaveOccurred())
-		Expect(resp.StatusCode).To(Equal(http.StatusUnauthorized))
+		err = createJobFromFile(ctx, j.WithCredentials("admin", invalidpassword), "failJob", "testdata/testjob.xml")
+		var statusErr *jenkins.StatusError
+		Expect(errors.As(err, &statusErr)).To(BeTrue())
+		Expect(statusErr.StatusCode).To(Equal(http.StatusUnauthorized))
 
 		By("checking the test job doesn't exist")
-		resp, err = j.GetJob("failJob", password)
-		Expect(err).NotTo(HaveOccurred())
-		Expect(resp.StatusCode).To(Equal(http.StatusNotFound))
+		_, err = admin.GetJob(ctx, "failJob")
+		Expect(jenkins.IsNotFound(err)).To(BeTrue())
 	}
 
 	It("should pass a smoke test", func() {
 		By("starting Jenkins")
-		err := j.Start(imageName, []string{})
+		err := j.Start(ctx, imageName, []string{})
 		Expect(err).NotTo(HaveOccurred())
 
 		smokeTest("password", "invalidpassword", true, basePlugins, additionalPlugins)
 
 		By("restarting Jenkins with a new password")
-		_, err = podmancli.ContainerStopAndRemove(j.ID, 30)
+		_, err = podmancli.ContainerStopAndRemove(ctx, j.ID, 30)
 		Expect(err).NotTo(HaveOccurred())
 
-		err = j.Start(imageName, []string{"JENKINS_PASSWORD=newpassword"})
+		err = j.Start(ctx, imageName, []string{"JENKINS_PASSWORD=newpassword"})
 		Expect(err).NotTo(HaveOccurred())
 
 		smokeTest("newpassword", "password", false, basePlugins, additionalPlugins)
@@ -152,7 +259,7 @@ var _ = Describe("Jenkins testing (v2)", func() {
 
 	It("should install plugins at startup", func() {
 		By("starting Jenkins")
-		err := j.Start(imageName, []string{"INSTALL_PLUGINS=ansicolor:0.4.1,greenballs"})
+		err := j.Start(ctx, imageName, []string{"INSTALL_PLUGINS=ansicolor:0.4.1,greenballs"})
 		Expect(err).NotTo(HaveOccurred())
 
 		var expectedPlugins []string
@@ -170,12 +277,6 @@ var _ = Describe("Jenkins testing (v2)", func() {
 		By("running s2i build")
 		destImage := fmt.Sprintf("jenkins-test-s2i-%d", rand.Intn(1e9))
 
-		By("set up podman debug")
-		ctx, cancel := context.WithTimeout(context.Background(), 25*time.Minute)
-		cmdstrs := []string{"ps", "-ef"}
-		go podmancli.ExecInActiveContainers(GinkgoWriter, ctx, cmdstrs)
-		go podmancli.InspectActiveContainers(GinkgoWriter, ctx)
-
 		cmd := exec.Cmd{
 			Path: s2i,
 			Args: []string{
@@ -192,12 +293,10 @@ var _ = Describe("Jenkins testing (v2)", func() {
 		}
 		err = cmd.Run()
 		Expect(err).NotTo(HaveOccurred())
-		cancel()
-
-		imageNamesToRemove = append(imageNamesToRemove, destImage)
+		podmancli.Tracker.Add(podman.ResourceImage, destImage)
 
 		By("starting Jenkins")
-		err = j.Start(destImage, nil)
+		err = j.Start(ctx, destImage, nil)
 		Expect(err).NotTo(HaveOccurred())
 
 		var expectedPlugins []string
@@ -206,42 +305,285 @@ var _ = Describe("Jenkins testing (v2)", func() {
 		smokeTest("password", "invalidpassword", true, expectedPlugins, nil)
 
 		By("checking sample-app-test job exists")
-		resp, err := j.GetJob("sample-app-test", "password")
+		_, err = j.GetJob(ctx, "sample-app-test")
 		Expect(err).NotTo(HaveOccurred())
-		Expect(resp.StatusCode).To(Equal(http.StatusOK))
 
 		By("checking files laid down by s2i exist")
-		code, _, err := podmancli.ContainerExec(j.ID, []string{"stat", "/var/lib/jenkins/plugins/sample.jpi.pinned"})
+		code, _, err := podmancli.ContainerExec(ctx, j.ID, []string{"stat", "/var/lib/jenkins/plugins/sample.jpi.pinned"})
 		Expect(err).NotTo(HaveOccurred())
 		Expect(code).To(Equal(0))
 
-		code, _, err = podmancli.ContainerExec(j.ID, []string{"stat", "/var/lib/jenkins/jobs/sample-app-test/config.xml"})
+		_, err = podmancli.ReadFileFromContainer(ctx, j.ID, "/var/lib/jenkins/jobs/sample-app-test/config.xml")
+		Expect(err).NotTo(HaveOccurred())
+
+		config, err := podmancli.ReadFileFromContainer(ctx, j.ID, "/var/lib/jenkins/config.xml")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(config)).To(ContainSubstring("s2i-test-config"))
+	})
+
+	It("should install plugins configured in a derived image", func() {
+		By("building a derived image")
+		destImage := fmt.Sprintf("jenkins-test-derived-%d", rand.Intn(1e9))
+		_, err := podmancli.ImageBuild(ctx, &podman.BuildOptions{
+			ContextDir:    "testdata/derived",
+			Containerfile: "Containerfile",
+			Tags:          []string{destImage},
+			Args: map[string]string{
+				"BASE_IMAGE":      imageName,
+				"INSTALL_PLUGINS": "ansicolor:0.4.1,greenballs",
+			},
+			Layers: true,
+			Output: GinkgoWriter,
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		By("starting Jenkins")
+		err = j.Start(ctx, destImage, nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		var expectedPlugins []string
+		expectedPlugins = append(expectedPlugins, basePlugins...)
+		expectedPlugins = append(expectedPlugins, additionalPlugins...)
+		smokeTest("password", "invalidpassword", true, expectedPlugins, nil)
+	})
+
+	It("should keep its jobs when JENKINS_HOME is restored from a snapshot", func() {
+		By("starting Jenkins")
+		err := j.Start(ctx, imageName, nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		err = podmancli.ContainerWaitForLog(ctx, j.ID, "Jenkins is fully up and running")
+		Expect(err).NotTo(HaveOccurred())
+
+		By("creating a test job")
+		err = createJobFromFile(ctx, j, "testJob", "testdata/testjob.xml")
+		Expect(err).NotTo(HaveOccurred())
+
+		By("exporting JENKINS_HOME")
+		_, err = podmancli.ContainerStopAndRemove(ctx, j.ID, 30)
+		Expect(err).NotTo(HaveOccurred())
+
+		var snapshot bytes.Buffer
+		err = podmancli.VolumeExport(ctx, j.Volume, &snapshot)
+		Expect(err).NotTo(HaveOccurred())
+
+		By("importing it into a new volume")
+		vcr, err := podmancli.VolumeCreate(ctx, nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		err = podmancli.VolumeImport(ctx, vcr.Name, &snapshot, nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		err = podmancli.VolumeRemove(ctx, j.Volume)
+		Expect(err).NotTo(HaveOccurred())
+		j.Volume = vcr.Name
+
+		By("restarting Jenkins on the restored volume")
+		err = j.Start(ctx, imageName, nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		smokeTest("password", "invalidpassword", false, basePlugins, additionalPlugins)
+	})
+
+	It("should run builds of a job", func() {
+		By("starting Jenkins")
+		err := j.Start(ctx, imageName, nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		err = podmancli.ContainerWaitForLog(ctx, j.ID, "Jenkins is fully up and running")
+		Expect(err).NotTo(HaveOccurred())
+
+		By("creating a job")
+		err = createJobFromFile(ctx, j, "echo", "testdata/echojob.xml")
+		Expect(err).NotTo(HaveOccurred())
+
+		By("running a successful build")
+		number, err := j.Build(ctx, "echo", map[string]string{"MESSAGE": "hello from the e2e suite"})
+		Expect(err).NotTo(HaveOccurred())
+		var console bytes.Buffer
+		err = j.StreamConsole(ctx, "echo", number, io.MultiWriter(&console, GinkgoWriter))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(console.String()).To(ContainSubstring("hello from the e2e suite"))
+		Expect(console.String()).To(ContainSubstring("Finished: SUCCESS"))
+
+		build, err := j.WaitForBuild(ctx, "echo", number)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(build.Result).To(Equal(jenkins.ResultSuccess))
+		Expect(build.URL).To(HaveSuffix(fmt.Sprintf("/job/echo/%d/", number)))
+
+		By("running a failing build")
+		number, err = j.Build(ctx, "echo", map[string]string{"FAIL": "true"})
+		Expect(err).NotTo(HaveOccurred())
+		err = j.StreamConsole(ctx, "echo", number, GinkgoWriter)
+		Expect(err).NotTo(HaveOccurred())
+
+		build, err = j.WaitForBuild(ctx, "echo", number)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(build.Result).To(Equal(jenkins.ResultFailure))
+	})
+
+	It("should run the plugin versions listed in bundle-plugins.txt", func() {
+		By("starting Jenkins")
+		err := j.Start(ctx, imageName, nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		err = podmancli.ContainerWaitForLog(ctx, j.ID, "Jenkins is fully up and running")
+		Expect(err).NotTo(HaveOccurred())
+
+		plugins, err := j.Plugins(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		versions := map[string]string{}
+		for _, plugin := range plugins {
+			Expect(plugin.Active).To(BeTrue(), "plugin %s is not active", plugin.ShortName)
+			versions[plugin.ShortName] = plugin.Version
+		}
+
+		bundle, err := os.ReadFile("../contrib/openshift/bundle-plugins.txt")
+		Expect(err).NotTo(HaveOccurred())
+		for _, line := range strings.Split(string(bundle), "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			name, version, _ := strings.Cut(line, ":")
+			Expect(versions).To(HaveKeyWithValue(name, version))
+		}
+	})
+
+	It("should configure the oc tool installations at startup", func() {
+		By("starting Jenkins")
+		err := j.Start(ctx, imageName, nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		err = podmancli.ContainerWaitForLog(ctx, j.ID, "Jenkins is fully up and running")
+		Expect(err).NotTo(HaveOccurred())
+
+		By("listing the installations through the script console")
+		out, err := j.RunScript(ctx, `
+			import jenkins.model.Jenkins
+			import com.openshift.jenkins.plugins.OpenShiftClientTools
+			Jenkins.instance.getDescriptor(OpenShiftClientTools).installations.each {
+				println "${it.name}=${it.home}"
+			}
+		`)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(strings.Fields(out)).To(ContainElement("oc-4.20=/usr/share/openshift/bin/oc-420"))
+
+		By("reporting exceptions thrown by scripts")
+		_, err = j.RunScript(ctx, "throw new IllegalStateException('expected')")
+		var scriptErr *jenkins.ScriptError
+		Expect(errors.As(err, &scriptErr)).To(BeTrue())
+		Expect(scriptErr.Exception).To(Equal("java.lang.IllegalStateException"))
+		Expect(scriptErr.Message).To(Equal("expected"))
+
+		By("refusing anonymous clients")
+		_, err = j.WithCredentials("", "").RunScript(ctx, "println 1")
+		Expect(err).To(MatchError(jenkins.ErrNotAdministrator))
+	})
+
+	It("should manage jobs in folders", func() {
+		By("starting Jenkins")
+		err := j.Start(ctx, imageName, nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		err = podmancli.ContainerWaitForLog(ctx, j.ID, "Jenkins is fully up and running")
+		Expect(err).NotTo(HaveOccurred())
+
+		By("creating a job in a folder")
+		err = createJobFromFile(ctx, j, "team", "testdata/folder.xml")
+		Expect(err).NotTo(HaveOccurred())
+		err = createJobFromFile(ctx, j, "team/echo", "testdata/echojob.xml")
+		Expect(err).NotTo(HaveOccurred())
+
+		job, err := j.GetJob(ctx, "team/echo")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(job.FullName).To(Equal("team/echo"))
+		Expect(job.Buildable).To(BeTrue())
+
+		By("updating its configuration")
+		config, err := j.GetJobConfig(ctx, "team/echo")
+		Expect(err).NotTo(HaveOccurred())
+		config = bytes.Replace(config, []byte("Echoes MESSAGE"), []byte("Updated job"), 1)
+		err = j.UpdateJob(ctx, "team/echo", bytes.NewReader(config))
+		Expect(err).NotTo(HaveOccurred())
+		job, err = j.GetJob(ctx, "team/echo")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(job.Description).To(HavePrefix("Updated job"))
+
+		By("copying, renaming and disabling it")
+		err = j.CopyJob(ctx, "team/echo", "team/copy")
+		Expect(err).NotTo(HaveOccurred())
+		err = j.RenameJob(ctx, "team/copy", "renamed")
+		Expect(err).NotTo(HaveOccurred())
+		err = j.DisableJob(ctx, "team/renamed")
+		Expect(err).NotTo(HaveOccurred())
+		job, err = j.GetJob(ctx, "team/renamed")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(job.Disabled).To(BeTrue())
+
+		By("listing jobs recursively")
+		jobs, err := j.ListJobs(ctx, "")
+		Expect(err).NotTo(HaveOccurred())
+		var names []string
+		for _, job := range jobs {
+			names = append(names, job.FullName)
+		}
+		Expect(names).To(ConsistOf("team", "team/echo", "team/renamed"))
+
+		By("deleting the folder")
+		err = j.DeleteJob(ctx, "team")
+		Expect(err).NotTo(HaveOccurred())
+		_, err = j.GetJob(ctx, "team/echo")
+		Expect(jenkins.IsNotFound(err)).To(BeTrue())
+	})
+
+	It("should accept jobs with CSRF protection enabled", func() {
+		By("starting Jenkins with a crumb issuer")
+		err := j.Start(ctx, imageName, []string{
+			"JENKINS_JAVA_OVERRIDES=-Dhudson.security.csrf.GlobalCrumbIssuerConfiguration.DISABLE_CSRF_PROTECTION=false",
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		smokeTest("password", "invalidpassword", true, basePlugins, additionalPlugins)
+	})
+
+	It("should run under the restricted SCC with a memory limit", func() {
+		By("starting Jenkins as an arbitrary UID with GID 0")
+		j.Restricted = true
+		j.Memory = 1 << 30
+		err := j.Start(ctx, imageName, nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		smokeTest("password", "invalidpassword", true, basePlugins, additionalPlugins)
+
+		By("running as a UID unknown to the image")
+		code, out, err := podmancli.ContainerExec(ctx, j.ID, []string{"id", "-g"})
 		Expect(err).NotTo(HaveOccurred())
 		Expect(code).To(Equal(0))
+		Expect(strings.TrimSpace(string(out))).To(Equal("0"))
 
-		code, _, err = podmancli.ContainerExec(j.ID, []string{"grep", "-q", "s2i-test-config", "/var/lib/jenkins/config.xml"})
+		code, out, err = podmancli.ContainerExec(ctx, j.ID, []string{"id", "-u"})
 		Expect(err).NotTo(HaveOccurred())
 		Expect(code).To(Equal(0))
+		Expect(strings.TrimSpace(string(out))).NotTo(Equal("1001"))
 	})
 
 	It("should handle spaces in command line arguments correctly", func() {
 		By("starting Jenkins")
-		err := j.Start(imageName, []string{`JENKINS_JAVA_OVERRIDES=-Dcontains\ space -Dnospace`})
+		err := j.Start(ctx, imageName, []string{`JENKINS_JAVA_OVERRIDES=-Dcontains\ space -Dnospace`})
 		Expect(err).NotTo(HaveOccurred())
 
 		By("checking resolved command line arguments")
-		_, bytes, err := podmancli.ContainerExec(j.ID, []string{"find", "/proc", "-name", "cmdline"})
+		// Print one process command line per line; processes that exit
+		// while the loop runs only produce noise on stderr.
+		res, err := podmancli.ContainerExecWithOptions(ctx, j.ID, []string{
+			"sh", "-c", `for f in /proc/[0-9]*/cmdline; do cat "$f"; echo; done`,
+		}, &podman.ExecOptions{Timeout: time.Minute})
 		Expect(err).NotTo(HaveOccurred())
-		output := string(bytes)
-		lines := strings.Split(output, "\n")
+		Expect(res.ExitCode).To(Equal(0))
 		found := false
-		for _, line := range lines {
-			_, bytes, err = podmancli.ContainerExec(j.ID, []string{"cat", line})
-			if err != nil {
-				continue
-			}
-			cat := string(bytes)
-			if strings.Contains(cat, `-Dcontains space`) && strings.Contains(cat, `-Dnospace`) {
+		for _, cmdline := range strings.Split(string(res.Stdout), "\n") {
+			if strings.Contains(cmdline, `-Dcontains space`) && strings.Contains(cmdline, `-Dnospace`) {
 				found = true
 				break
 			}
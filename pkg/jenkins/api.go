@@ -0,0 +1,111 @@
+package jenkins
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// StatusError is returned when Jenkins answers a request with an unexpected
+// HTTP status.
+type StatusError struct {
+	Method     string
+	URL        string
+	StatusCode int
+	Status     string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s %s: %s", e.Method, e.URL, e.Status)
+}
+
+// statusError returns a StatusError describing resp.
+func statusError(resp *http.Response) error {
+	return &StatusError{
+		Method:     resp.Request.Method,
+		URL:        resp.Request.URL.Redacted(),
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+	}
+}
+
+// IsNotFound reports whether err is a 404 Not Found answer.
+func IsNotFound(err error) bool {
+	var statusErr *StatusError
+	return errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusNotFound
+}
+
+// jobPath returns the path of a job given by its full name, in which
+// folders are separated by slashes, e.g. "folder/job".
+func jobPath(name string) string {
+	var b strings.Builder
+	for _, part := range strings.Split(strings.Trim(name, "/"), "/") {
+		b.WriteString("/job/")
+		b.WriteString(url.PathEscape(part))
+	}
+	return b.String()
+}
+
+// relativePath returns the path of an absolute URL handed out by Jenkins,
+// such as a Location header, relative to the context path.
+func (j *Jenkins) relativePath(rawURL string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	path := u.EscapedPath()
+	if prefix := j.prefix(); prefix != "" {
+		rest, ok := strings.CutPrefix(path, prefix)
+		if !ok {
+			return "", fmt.Errorf("%s is not below %s", rawURL, j.URL("/"))
+		}
+		path = rest
+	}
+	return path, nil
+}
+
+// getJSON decodes the answer to a GET request for path into v.
+func (j *Jenkins) getJSON(ctx context.Context, path string, v any) error {
+	req, err := j.newRequest(ctx, "GET", path, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := j.do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return statusError(resp)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// post sends a POST request for path, failing unless Jenkins answers with
+// one of the expected status codes. The caller must close the body of the
+// response.
+func (j *Jenkins) post(ctx context.Context, path, contentType string, body io.Reader, expected ...int) (*http.Response, error) {
+	req, err := j.newRequest(ctx, "POST", path, body)
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	resp, err := j.do(req)
+	if err != nil {
+		return nil, err
+	}
+	for _, code := range expected {
+		if resp.StatusCode == code {
+			return resp, nil
+		}
+	}
+	resp.Body.Close()
+	return nil, statusError(resp)
+}
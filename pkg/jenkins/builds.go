@@ -0,0 +1,205 @@
+package jenkins
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// pollInterval is how often queue items and builds are polled.
+var pollInterval = time.Second
+
+// Build results as reported by Jenkins.
+const (
+	ResultSuccess  = "SUCCESS"
+	ResultUnstable = "UNSTABLE"
+	ResultFailure  = "FAILURE"
+	ResultNotBuilt = "NOT_BUILT"
+	ResultAborted  = "ABORTED"
+)
+
+// BuildInfo describes a build of a job.
+type BuildInfo struct {
+	Number   int
+	URL      string
+	Building bool
+	// Result is one of the Result constants, and empty while the build is
+	// running.
+	Result   string
+	Duration time.Duration
+}
+
+// Build triggers a build of job, with params if it is parameterized, and
+// returns its number once it leaves the queue. Folders in the name of the
+// job are separated by slashes. If ctx is done first, the build stays
+// queued.
+func (j *Jenkins) Build(ctx context.Context, job string, params map[string]string) (int, error) {
+	path := jobPath(job) + "/build"
+	var body string
+	if len(params) > 0 {
+		values := url.Values{}
+		for k, v := range params {
+			values.Set(k, v)
+		}
+		path = jobPath(job) + "/buildWithParameters"
+		body = values.Encode()
+	}
+	resp, err := j.post(ctx, path, "application/x-www-form-urlencoded", strings.NewReader(body), http.StatusCreated)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+
+	location := resp.Header.Get("Location")
+	if location == "" {
+		return 0, fmt.Errorf("triggering %s: no queue item in the response", job)
+	}
+	queueItem, err := j.relativePath(location)
+	if err != nil {
+		return 0, err
+	}
+	return j.waitForQueueItem(ctx, strings.TrimSuffix(queueItem, "/"))
+}
+
+// waitForQueueItem polls a queue item until a build is started for it.
+func (j *Jenkins) waitForQueueItem(ctx context.Context, path string) (int, error) {
+	// why is the reason Jenkins last gave for keeping the item queued.
+	var why string
+	giveUp := func(err error) error {
+		if ctx.Err() != nil && why != "" {
+			return fmt.Errorf("%w while queued: %s", err, why)
+		}
+		return err
+	}
+	for {
+		var item struct {
+			Cancelled  bool   `json:"cancelled"`
+			Why        string `json:"why"`
+			Executable *struct {
+				Number int `json:"number"`
+			} `json:"executable"`
+		}
+		if err := j.getJSON(ctx, path+"/api/json", &item); err != nil {
+			return 0, giveUp(err)
+		}
+		if item.Cancelled {
+			return 0, fmt.Errorf("queue item %s was cancelled", path)
+		}
+		if item.Executable != nil {
+			return item.Executable.Number, nil
+		}
+		why = item.Why
+
+		select {
+		case <-ctx.Done():
+			return 0, giveUp(ctx.Err())
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+// GetBuild returns the current state of build number of job.
+func (j *Jenkins) GetBuild(ctx context.Context, job string, number int) (*BuildInfo, error) {
+	var build struct {
+		Number   int    `json:"number"`
+		URL      string `json:"url"`
+		Building bool   `json:"building"`
+		Result   string `json:"result"`
+		Duration int64  `json:"duration"`
+	}
+	if err := j.getJSON(ctx, buildPath(job, number)+"/api/json", &build); err != nil {
+		return nil, err
+	}
+	return &BuildInfo{
+		Number:   build.Number,
+		URL:      build.URL,
+		Building: build.Building,
+		Result:   build.Result,
+		Duration: time.Duration(build.Duration) * time.Millisecond,
+	}, nil
+}
+
+// WaitForBuild waits for build number of job to finish and returns it. If
+// ctx is done first, the build keeps running; see StopBuild.
+func (j *Jenkins) WaitForBuild(ctx context.Context, job string, number int) (*BuildInfo, error) {
+	for {
+		build, err := j.GetBuild(ctx, job, number)
+		if err != nil {
+			return nil, err
+		}
+		if !build.Building {
+			return build, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+// StopBuild aborts build number of job.
+func (j *Jenkins) StopBuild(ctx context.Context, job string, number int) error {
+	// Jenkins redirects to the build page once it stopped the build.
+	resp, err := j.post(ctx, buildPath(job, number)+"/stop", "", nil, http.StatusOK, http.StatusFound)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// StreamConsole copies the console output of build number of job to w as
+// it is written, returning once the build finished and its output was
+// copied entirely.
+func (j *Jenkins) StreamConsole(ctx context.Context, job string, number int, w io.Writer) error {
+	path := buildPath(job, number) + "/logText/progressiveText?start="
+	var start int64
+	for {
+		req, err := j.newRequest(ctx, "GET", path+strconv.FormatInt(start, 10), nil)
+		if err != nil {
+			return err
+		}
+		resp, err := j.do(req)
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return statusError(resp)
+		}
+		n, err := io.Copy(w, resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return err
+		}
+
+		// X-Text-Size is the offset to continue from, and X-More-Data is
+		// only set while the build is running.
+		if size := resp.Header.Get("X-Text-Size"); size != "" {
+			if start, err = strconv.ParseInt(size, 10, 64); err != nil {
+				return fmt.Errorf("invalid X-Text-Size %q: %w", size, err)
+			}
+		} else {
+			start += n
+		}
+		if resp.Header.Get("X-More-Data") != "true" {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+func buildPath(job string, number int) string {
+	return jobPath(job) + "/" + strconv.Itoa(number)
+}
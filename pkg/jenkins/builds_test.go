@@ -0,0 +1,195 @@
+package jenkins
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Builds", func() {
+	var srv *httptest.Server
+	var mu sync.Mutex
+	var params map[string]string
+	var queuePolls, buildPolls int
+	var queued, running int
+	var stopped, cancelled bool
+	var console []string
+	var written int
+	var starts []int
+	var j *Jenkins
+	var savedPollInterval time.Duration
+
+	BeforeEach(func() {
+		savedPollInterval = pollInterval
+		pollInterval = time.Millisecond
+		params = nil
+		queuePolls, buildPolls = 0, 0
+		queued, running = 2, 3
+		stopped, cancelled = false, false
+		console = []string{"Started\n", "", "hello\n", "Finished: SUCCESS\n"}
+		written = 0
+		starts = nil
+
+		mux := http.NewServeMux()
+		mux.HandleFunc("/jenkins/crumbIssuer/api/json", http.NotFound)
+		for _, trigger := range []string{"build", "buildWithParameters"} {
+			mux.HandleFunc("POST /jenkins/job/folder/job/app/"+trigger, func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				defer mu.Unlock()
+				Expect(r.ParseForm()).To(Succeed())
+				params = map[string]string{}
+				for k := range r.PostForm {
+					params[k] = r.PostForm.Get(k)
+				}
+				w.Header().Set("Location", "http://"+r.Host+"/jenkins/queue/item/7/")
+				w.WriteHeader(http.StatusCreated)
+			})
+		}
+		mux.HandleFunc("GET /jenkins/queue/item/7/api/json", func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			defer mu.Unlock()
+			queuePolls++
+			switch {
+			case cancelled:
+				fmt.Fprint(w, `{"cancelled":true}`)
+			case queuePolls <= queued:
+				fmt.Fprint(w, `{"why":"Waiting for next available executor"}`)
+			default:
+				fmt.Fprint(w, `{"executable":{"number":42}}`)
+			}
+		})
+		mux.HandleFunc("GET /jenkins/job/folder/job/app/42/api/json", func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			defer mu.Unlock()
+			buildPolls++
+			switch {
+			case stopped:
+				fmt.Fprint(w, `{"number":42,"url":"u","building":false,"result":"ABORTED","duration":10}`)
+			case buildPolls <= running:
+				fmt.Fprint(w, `{"number":42,"url":"u","building":true,"result":null}`)
+			default:
+				fmt.Fprint(w, `{"number":42,"url":"u","building":false,"result":"SUCCESS","duration":1500}`)
+			}
+		})
+		mux.HandleFunc("POST /jenkins/job/folder/job/app/42/stop", func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			defer mu.Unlock()
+			stopped = true
+		})
+		mux.HandleFunc("GET /jenkins/job/folder/job/app/42/logText/progressiveText", func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			defer mu.Unlock()
+			start, err := strconv.Atoi(r.URL.Query().Get("start"))
+			Expect(err).NotTo(HaveOccurred())
+			starts = append(starts, start)
+			// The console grows by one chunk per request until all of
+			// them were written.
+			if written < len(console) {
+				written++
+			}
+			text := strings.Join(console[:written], "")
+			w.Header().Set("X-Text-Size", strconv.Itoa(len(text)))
+			if written < len(console) {
+				w.Header().Set("X-More-Data", "true")
+			}
+			fmt.Fprint(w, text[start:])
+		})
+		srv = httptest.NewServer(mux)
+		j = NewClient(srv.URL, &ClientOptions{ContextPath: "/jenkins"})
+	})
+
+	AfterEach(func() {
+		srv.Close()
+		pollInterval = savedPollInterval
+	})
+
+	It("should follow a build from the queue to its result", func() {
+		number, err := j.Build(context.Background(), "folder/app", map[string]string{"BRANCH": "main"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(number).To(Equal(42))
+		Expect(params).To(Equal(map[string]string{"BRANCH": "main"}))
+		Expect(queuePolls).To(Equal(3))
+
+		build, err := j.WaitForBuild(context.Background(), "folder/app", number)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(build).To(Equal(&BuildInfo{
+			Number:   42,
+			URL:      "u",
+			Result:   ResultSuccess,
+			Duration: 1500 * time.Millisecond,
+		}))
+		Expect(buildPolls).To(Equal(4))
+	})
+
+	It("should trigger builds without parameters", func() {
+		number, err := j.Build(context.Background(), "folder/app", nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(number).To(Equal(42))
+		Expect(params).To(BeEmpty())
+	})
+
+	It("should report a cancelled queue item", func() {
+		cancelled = true
+
+		_, err := j.Build(context.Background(), "folder/app", nil)
+		Expect(err).To(MatchError(ContainSubstring("cancelled")))
+	})
+
+	It("should report why a build is still queued when giving up", func() {
+		queued = 1000
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		_, err := j.Build(ctx, "folder/app", nil)
+		Expect(err).To(MatchError(context.DeadlineExceeded))
+		Expect(err).To(MatchError(ContainSubstring("Waiting for next available executor")))
+	})
+
+	It("should stop waiting without stopping the build", func() {
+		running = 1000
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		_, err := j.WaitForBuild(ctx, "folder/app", 42)
+		Expect(err).To(MatchError(context.DeadlineExceeded))
+		Expect(stopped).To(BeFalse())
+
+		Expect(j.StopBuild(context.Background(), "folder/app", 42)).To(Succeed())
+		build, err := j.WaitForBuild(context.Background(), "folder/app", 42)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(build.Result).To(Equal(ResultAborted))
+	})
+
+	It("should stream the console output until the build finishes", func() {
+		var out bytes.Buffer
+		Expect(j.StreamConsole(context.Background(), "folder/app", 42, &out)).To(Succeed())
+		Expect(out.String()).To(Equal("Started\nhello\nFinished: SUCCESS\n"))
+		Expect(starts).To(Equal([]int{0, 8, 8, 14}))
+	})
+
+	It("should stop streaming when the context is done", func() {
+		console = append(console[:1], make([]string, 1000)...)
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		var out bytes.Buffer
+		err := j.StreamConsole(ctx, "folder/app", 42, &out)
+		Expect(err).To(MatchError(context.DeadlineExceeded))
+		Expect(out.String()).To(Equal("Started\n"))
+	})
+
+	It("should report unknown jobs", func() {
+		_, err := j.Build(context.Background(), "missing", nil)
+		Expect(IsNotFound(err)).To(BeTrue())
+		Expect(err).To(MatchError(ContainSubstring("404")))
+	})
+})
@@ -0,0 +1,84 @@
+package jenkins
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// crumb is a CSRF token that Jenkins expects on mutating requests, in the
+// header named by field. Crumbs are tied to the web session, so they are sent
+// along with the session cookie kept by the cookie jar of the HTTP client.
+type crumb struct {
+	field string
+	value string
+}
+
+// crumbCache holds the crumb of a client. A nil crumb with fetched set means
+// that CSRF protection is disabled.
+type crumbCache struct {
+	mu      sync.Mutex
+	fetched bool
+	crumb   *crumb
+}
+
+// mutating reports whether requests with method need a crumb.
+func mutating(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	}
+	return true
+}
+
+// crumb returns the cached crumb, fetching it from the crumb issuer first if
+// needed. It returns nil if CSRF protection is disabled.
+func (j *Jenkins) crumb(ctx context.Context) (*crumb, error) {
+	cache := j.crumbs
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	if cache.fetched {
+		return cache.crumb, nil
+	}
+
+	req, err := j.newRequest(ctx, "GET", "/crumbIssuer/api/json", nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := j.send(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		// No crumb issuer is configured.
+		cache.fetched = true
+		return nil, nil
+	default:
+		return nil, statusError(resp)
+	}
+	var body struct {
+		Crumb             string `json:"crumb"`
+		CrumbRequestField string `json:"crumbRequestField"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("fetching CSRF crumb: %w", err)
+	}
+	cache.fetched = true
+	cache.crumb = &crumb{field: body.CrumbRequestField, value: body.Crumb}
+	return cache.crumb, nil
+}
+
+// forgetCrumb drops the cached crumb so that the next mutating request
+// fetches a new one.
+func (j *Jenkins) forgetCrumb() {
+	j.crumbs.mu.Lock()
+	defer j.crumbs.mu.Unlock()
+	j.crumbs.fetched = false
+	j.crumbs.crumb = nil
+}
@@ -1,106 +1,319 @@
 package jenkins
 
 import (
+	"bytes"
 	"context"
-	"errors"
+	"crypto/tls"
+	"fmt"
+	"io"
 	"net/http"
-	"os"
+	"net/http/cookiejar"
+	"strings"
 	"time"
 
-	"github.com/containers/podman/v5/pkg/specgen"
+	"github.com/containers/podman/v5/libpod/define"
+
 	"github.com/openshift/jenkins/pkg/podman"
 )
 
+// DefaultTimeout bounds each HTTP request made by a Jenkins without its
+// own HTTPClient.
+const DefaultTimeout = 30 * time.Second
+
+// Jenkins is a Jenkins server reached over HTTP, optionally running in a
+// container managed through Client.
 type Jenkins struct {
-	ID     string
-	ip     string
-	Volume string
-	Client *podman.Client
+	ID string
+	// BaseURL is the scheme, host and port Jenkins is served at, e.g.
+	// "https://ci.example.com". Start sets it to the host port the web port
+	// of the container is published on.
+	BaseURL string
+	// ContextPath is the path Jenkins is served under, as set with its
+	// --prefix option, e.g. "/jenkins".
+	ContextPath string
+	// Username authenticates requests together with APIToken or, failing
+	// that, Password. Requests are anonymous without a username.
+	Username string
+	Password string
+	APIToken string
+	// HTTPClient sends the requests. Its cookie jar keeps the web session
+	// that CSRF crumbs are tied to.
+	HTTPClient *http.Client
+	crumbs     *crumbCache
+	Volume     string
+	// Network, when set, is joined by the container under the alias
+	// "jenkins", so agents on the same network can connect to the master.
+	Network string
+	// Restricted runs the container like OpenShift's restricted SCC, as an
+	// arbitrary UID with GID 0.
+	Restricted bool
+	// Memory, when positive, limits the memory of the container in bytes.
+	Memory int64
+	Client podman.Runtime
 }
 
-func NewJenkins(client *podman.Client) *Jenkins {
-	return &Jenkins{Client: client}
+// ClientOptions configures how NewClient reaches an existing Jenkins.
+type ClientOptions struct {
+	ContextPath string
+	Username    string
+	Password    string
+	APIToken    string
+	// Timeout bounds each request. It defaults to DefaultTimeout.
+	Timeout time.Duration
+	// TLSConfig, when set, is used for https base URLs, for example to
+	// trust a private CA.
+	TLSConfig *tls.Config
 }
 
-func (j *Jenkins) CreateJob(name, password, filename string) (*http.Response, error) {
-	xml, err := os.Open(filename)
-	if err != nil {
-		return nil, err
+// NewJenkins returns a Jenkins to be run in a container with Start. It
+// authenticates as the admin user of the image with its default password;
+// Start picks up a password set through JENKINS_PASSWORD.
+func NewJenkins(client podman.Runtime) *Jenkins {
+	return &Jenkins{
+		Username:   "admin",
+		Password:   "password",
+		HTTPClient: newHTTPClient(DefaultTimeout, nil),
+		crumbs:     &crumbCache{},
+		Client:     client,
+	}
+}
+
+// NewClient returns a Jenkins already being served at baseURL.
+func NewClient(baseURL string, opts *ClientOptions) *Jenkins {
+	if opts == nil {
+		opts = &ClientOptions{}
+	}
+	timeout := opts.Timeout
+	if timeout == 0 {
+		timeout = DefaultTimeout
+	}
+	return &Jenkins{
+		BaseURL:     baseURL,
+		ContextPath: opts.ContextPath,
+		Username:    opts.Username,
+		Password:    opts.Password,
+		APIToken:    opts.APIToken,
+		HTTPClient:  newHTTPClient(timeout, opts.TLSConfig),
+		crumbs:      &crumbCache{},
+	}
+}
+
+func newHTTPClient(timeout time.Duration, tlsConfig *tls.Config) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	return &http.Client{Transport: transport, Timeout: timeout, Jar: newJar()}
+}
+
+func newJar() http.CookieJar {
+	// cookiejar.New only fails on invalid options.
+	jar, _ := cookiejar.New(nil)
+	return jar
+}
+
+// WithCredentials returns a copy of j authenticating as username with
+// password instead, in a session of its own.
+func (j *Jenkins) WithCredentials(username, password string) *Jenkins {
+	c := *j
+	c.Username, c.Password, c.APIToken = username, password, ""
+	if j.HTTPClient != nil && j.HTTPClient.Jar != nil {
+		client := *j.HTTPClient
+		client.Jar = newJar()
+		c.HTTPClient = &client
+	}
+	c.crumbs = &crumbCache{}
+	return &c
+}
+
+// URL returns the absolute URL of path, which starts with a slash and is
+// relative to the context path.
+func (j *Jenkins) URL(path string) string {
+	return strings.TrimSuffix(j.BaseURL, "/") + j.prefix() + path
+}
+
+// prefix returns the context path with a leading slash and no trailing
+// one, or the empty string.
+func (j *Jenkins) prefix() string {
+	if prefix := strings.Trim(j.ContextPath, "/"); prefix != "" {
+		return "/" + prefix
 	}
-	defer xml.Close()
+	return ""
+}
 
-	req, err := http.NewRequest("POST", "http://"+j.ip+":8080/createItem?name="+name, xml)
+// newRequest returns an authenticated request for path.
+func (j *Jenkins) newRequest(ctx context.Context, method, path string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, j.URL(path), body)
 	if err != nil {
 		return nil, err
 	}
-	req.SetBasicAuth("admin", password)
-	req.Header.Set("Content-Type", "application/xml")
-
-	return http.DefaultClient.Do(req)
+	if j.Username != "" {
+		secret := j.APIToken
+		if secret == "" {
+			secret = j.Password
+		}
+		req.SetBasicAuth(j.Username, secret)
+	}
+	return req, nil
 }
 
-func (j *Jenkins) GetJob(name, password string) (*http.Response, error) {
-	req, err := http.NewRequest("GET", "http://"+j.ip+":8080/job/"+name, nil)
+// do sends req, adding a CSRF crumb to mutating requests. A request
+// rejected with 403 Forbidden is retried once with a fresh crumb, as the
+// session the crumb belonged to may have expired, e.g. when Jenkins
+// restarted.
+func (j *Jenkins) do(req *http.Request) (*http.Response, error) {
+	if !mutating(req.Method) {
+		return j.send(req)
+	}
+	if j.crumbs == nil {
+		j.crumbs = &crumbCache{}
+	}
+	ctx := req.Context()
+	c, err := j.crumb(ctx)
 	if err != nil {
 		return nil, err
 	}
-	req.SetBasicAuth("admin", password)
+	if c != nil {
+		req.Header.Set(c.field, c.value)
+	}
+	resp, err := j.send(req)
+	if err != nil || resp.StatusCode != http.StatusForbidden {
+		return resp, err
+	}
+	if req.Body != nil && req.GetBody == nil {
+		return resp, nil
+	}
 
-	return http.DefaultClient.Do(req)
+	j.forgetCrumb()
+	c, err = j.crumb(ctx)
+	if err != nil || c == nil {
+		// Report the rejection rather than why no crumb could be had.
+		return resp, nil
+	}
+	retry := req.Clone(ctx)
+	if req.GetBody != nil {
+		if retry.Body, err = req.GetBody(); err != nil {
+			return resp, nil
+		}
+	}
+	resp.Body.Close()
+	// The client added the cookies of the old session to req.
+	retry.Header.Del("Cookie")
+	retry.Header.Set(c.field, c.value)
+	return j.send(retry)
+}
+
+// send sends req as is.
+func (j *Jenkins) send(req *http.Request) (*http.Response, error) {
+	client := j.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return client.Do(req)
 }
 
-func (j *Jenkins) Start(image string, env []string) error {
+func (j *Jenkins) Start(ctx context.Context, image string, env []string) error {
 	var err error
-	sgen := specgen.NewSpecGenerator(image, false)
-	var terminal = true
-	sgen.Terminal = &terminal
-	sgen.Volumes = []*specgen.NamedVolume{{Dest: "/var/lib/jenkins", Name: j.Volume, Options: []string{"rw"}}}
-	j.ID, err = j.Client.ContainerCreate(sgen)
+	spec := podman.NewSpec(image).
+		Terminal().
+		Env(env...).
+		Volume(j.Volume, "/var/lib/jenkins").
+		Ports(8080).
+		HealthCheck(podman.HealthCheck{
+			Command:     "curl -sf -o /dev/null http://localhost:8080" + j.prefix() + "/login",
+			Interval:    10 * time.Second,
+			Timeout:     5 * time.Second,
+			StartPeriod: 30 * time.Second,
+			Retries:     3,
+		})
+	if j.Network != "" {
+		spec.Network(j.Network, "jenkins")
+	}
+	if j.Restricted {
+		spec.Restricted()
+	}
+	if j.Memory > 0 {
+		spec.Memory(j.Memory)
+	}
+	for _, kv := range env {
+		if password, ok := strings.CutPrefix(kv, "JENKINS_PASSWORD="); ok {
+			j.Password = password
+		}
+	}
+	j.ID, err = j.Client.ContainerCreate(ctx, spec.Build())
 	if err != nil {
 		return err
 	}
 
-	err = j.Client.ContainerStart(j.ID)
+	// Subscribe before starting so that an immediate exit is not missed.
+	evctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	events, err := j.Client.ContainerEvents(evctx, &podman.EventFilter{
+		ContainerIDs: []string{j.ID},
+		Kinds:        []podman.EventKind{podman.EventDie},
+	})
 	if err != nil {
 		return err
 	}
 
-	j.ip, err = j.Client.ContainerInspect(j.ID)
+	err = j.Client.ContainerStart(ctx, j.ID)
 	if err != nil {
 		return err
 	}
 
-	return j.wait()
+	if err := j.wait(ctx, events); err != nil {
+		return err
+	}
+
+	addr, err := j.Client.ContainerHostPort(ctx, j.ID, 8080)
+	if err != nil {
+		return err
+	}
+	j.BaseURL = "http://" + addr
+	return nil
 }
 
-func (j *Jenkins) wait() error {
-	ctx, cancel := context.WithTimeout(context.Background(), 8*time.Minute)
+// wait waits for the healthcheck, which fetches the login page, to pass,
+// failing as soon as the container exits.
+func (j *Jenkins) wait(ctx context.Context, events <-chan podman.ContainerEvent) error {
+	ctx, cancel := context.WithTimeout(ctx, 8*time.Minute)
 	defer cancel()
 
-	for {
-		reqctx, cancel := context.WithTimeout(ctx, time.Second)
-		defer cancel()
-
-		req, err := http.NewRequest("GET", "http://"+j.ip+":8080/login", nil)
-		if err != nil {
-			return err
-		}
-		req = req.WithContext(reqctx)
+	type result struct {
+		data *define.InspectContainerData
+		err  error
+	}
+	healthy := make(chan result, 1)
+	go func() {
+		data, err := j.Client.WaitFor(ctx, j.ID, podman.ConditionHealthy)
+		healthy <- result{data, err}
+	}()
 
-		resp, err := http.DefaultClient.Do(req)
-		if err == nil {
-			defer resp.Body.Close()
-			if resp.StatusCode == http.StatusOK {
-				return nil
+	for {
+		select {
+		case res := <-healthy:
+			// The exit may be noticed before its event arrives.
+			if res.err != nil && ctx.Err() == nil && res.data != nil && !res.data.State.Running {
+				return j.exited(int(res.data.State.ExitCode))
+			}
+			return res.err
+		case ev, ok := <-events:
+			if !ok {
+				events = nil
+				continue
 			}
+			return j.exited(ev.ExitCode)
 		}
+	}
+}
 
-		select {
-		case <-ctx.Done():
-			return errors.New("timeout")
-		default:
-		}
+// exited reports a container that died during startup along with the tail
+// of its log.
+func (j *Jenkins) exited(exitCode int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
 
-		<-reqctx.Done()
+	var logs bytes.Buffer
+	if err := j.Client.ContainerStreamLogs(ctx, j.ID, &podman.LogOptions{Tail: 50}, &logs, &logs); err != nil {
+		fmt.Fprintf(&logs, "(reading logs failed: %v)", err)
 	}
+	return fmt.Errorf("container %s exited with code %d during startup:\n%s", j.ID, exitCode, logs.String())
 }
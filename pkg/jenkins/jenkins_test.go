@@ -0,0 +1,300 @@
+package jenkins
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/containers/podman/v5/libpod/define"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/openshift/jenkins/pkg/podman"
+	"github.com/openshift/jenkins/pkg/podman/fake"
+)
+
+func Test(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Jenkins Suite")
+}
+
+var _ = Describe("Jenkins", func() {
+	var rt *fake.Runtime
+	var srv *httptest.Server
+	var ctx context.Context
+	var cancel context.CancelFunc
+
+	BeforeEach(func() {
+		ctx, cancel = context.WithTimeout(context.Background(), time.Minute)
+		srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("{}"))
+		}))
+		rt = fake.New()
+		rt.HostPorts = map[uint16]string{8080: srv.Listener.Addr().String()}
+	})
+
+	AfterEach(func() {
+		cancel()
+		srv.Close()
+	})
+
+	It("should start a container serving the login page", func() {
+		j := NewJenkins(rt)
+		j.Volume = "jenkins-home"
+		Expect(j.Start(ctx, "jenkins", []string{"JENKINS_PASSWORD=secret"})).To(Succeed())
+
+		ctr := rt.Container(j.ID)
+		Expect(ctr).NotTo(BeNil())
+		Expect(ctr.Running).To(BeTrue())
+		Expect(ctr.Spec.Image).To(Equal("jenkins"))
+		Expect(ctr.Spec.Env).To(HaveKeyWithValue("JENKINS_PASSWORD", "secret"))
+		Expect(ctr.Spec.Volumes).To(HaveLen(1))
+		Expect(ctr.Spec.Volumes[0].Name).To(Equal("jenkins-home"))
+		Expect(ctr.Spec.HealthConfig).NotTo(BeNil())
+		Expect(ctr.HostPorts).To(HaveKey(uint16(8080)))
+
+		Expect(j.Password).To(Equal("secret"))
+		_, err := j.GetJob(ctx, "test")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should check the login page below the context path", func() {
+		j := NewJenkins(rt)
+		j.ContextPath = "/jenkins/"
+		Expect(j.Start(ctx, "jenkins", []string{"JENKINS_OPTS=--prefix=/jenkins"})).To(Succeed())
+
+		hc := rt.Container(j.ID).Spec.HealthConfig
+		Expect(hc.Test).To(Equal([]string{"CMD-SHELL", "curl -sf -o /dev/null http://localhost:8080/jenkins/login"}))
+	})
+
+	It("should wait for the container to become healthy", func() {
+		rt.OnStart = func(id string) {
+			defer GinkgoRecover()
+			Expect(rt.SetHealth(id, define.HealthCheckStarting)).To(Succeed())
+			time.AfterFunc(10*time.Millisecond, func() {
+				rt.SetHealth(id, define.HealthCheckHealthy)
+			})
+		}
+
+		j := NewJenkins(rt)
+		Expect(j.Start(ctx, "jenkins", nil)).To(Succeed())
+		Expect(rt.CallsTo("WaitFor")).To(HaveLen(1))
+		Expect(rt.Container(j.ID).Health).To(Equal(define.HealthCheckHealthy))
+	})
+
+	It("should give up on a container that never becomes healthy", func() {
+		rt.OnStart = func(id string) {
+			defer GinkgoRecover()
+			Expect(rt.SetHealth(id, define.HealthCheckUnhealthy)).To(Succeed())
+		}
+		ctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+
+		j := NewJenkins(rt)
+		Expect(j.Start(ctx, "jenkins", nil)).To(MatchError(context.DeadlineExceeded))
+	})
+
+	It("should report the logs of a container exiting during startup", func() {
+		rt.OnStart = func(id string) {
+			defer GinkgoRecover()
+			Expect(rt.SetHealth(id, define.HealthCheckStarting)).To(Succeed())
+			Expect(rt.AppendLogs(id, []byte("starting\nSEVERE: boom\n"))).To(Succeed())
+			rt.Emit(podman.ContainerEvent{Kind: podman.EventDie, ContainerID: id, ExitCode: 1})
+		}
+
+		j := NewJenkins(rt)
+		err := j.Start(ctx, "jenkins", nil)
+		Expect(err).To(MatchError(ContainSubstring("exited with code 1")))
+		Expect(err).To(MatchError(ContainSubstring("SEVERE: boom")))
+		Expect(rt.CallsTo("ContainerStreamLogs")).To(HaveLen(1))
+	})
+
+	It("should not start a container that could not be created", func() {
+		rt.FailNext("ContainerCreate", context.DeadlineExceeded)
+
+		j := NewJenkins(rt)
+		Expect(j.Start(ctx, "jenkins", nil)).To(MatchError(context.DeadlineExceeded))
+		Expect(rt.CallsTo("ContainerStart")).To(BeEmpty())
+	})
+})
+
+var _ = Describe("Client", func() {
+	var srv *httptest.Server
+	var requests []*http.Request
+
+	BeforeEach(func() {
+		requests = nil
+		srv = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests = append(requests, r)
+			w.Write([]byte("{}"))
+		}))
+	})
+
+	AfterEach(func() {
+		srv.Close()
+	})
+
+	It("should send authenticated requests below the context path", func() {
+		j := NewClient(srv.URL+"/", &ClientOptions{
+			ContextPath: "/jenkins/",
+			Username:    "robot",
+			Password:    "unused",
+			APIToken:    "token",
+			Timeout:     time.Minute,
+			TLSConfig:   srv.Client().Transport.(*http.Transport).TLSClientConfig,
+		})
+
+		_, err := j.GetJob(context.Background(), "my job")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(requests).To(HaveLen(1))
+		Expect(requests[0].URL.Path).To(Equal("/jenkins/job/my job/api/json"))
+		username, password, ok := requests[0].BasicAuth()
+		Expect(ok).To(BeTrue())
+		Expect(username).To(Equal("robot"))
+		Expect(password).To(Equal("token"))
+	})
+
+	It("should send anonymous requests without a username", func() {
+		j := NewClient(srv.URL, &ClientOptions{
+			TLSConfig: srv.Client().Transport.(*http.Transport).TLSClientConfig,
+		})
+
+		_, err := j.GetJob(context.Background(), "test")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(requests).To(HaveLen(1))
+		Expect(requests[0].URL.Path).To(Equal("/job/test/api/json"))
+		_, _, ok := requests[0].BasicAuth()
+		Expect(ok).To(BeFalse())
+	})
+
+	It("should not trust an unknown CA", func() {
+		j := NewClient(srv.URL, nil)
+
+		_, err := j.GetJob(context.Background(), "test")
+		Expect(err).To(MatchError(ContainSubstring("certificate")))
+		Expect(requests).To(BeEmpty())
+	})
+})
+
+var _ = Describe("CSRF crumbs", func() {
+	var srv *httptest.Server
+	var mu sync.Mutex
+	var session int
+	var issued, posts int
+	var crumbIssuer, unauthorized bool
+	var j *Jenkins
+
+	crumbFor := func(session string) string {
+		return "crumb-" + session
+	}
+
+	BeforeEach(func() {
+		session, issued, posts = 1, 0, 0
+		crumbIssuer, unauthorized = true, false
+		srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			defer mu.Unlock()
+			current := strconv.Itoa(session)
+			switch r.URL.Path {
+			case "/crumbIssuer/api/json":
+				if unauthorized {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				if !crumbIssuer {
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+				issued++
+				http.SetCookie(w, &http.Cookie{Name: "JSESSIONID", Value: current, Path: "/"})
+				w.Write([]byte(`{"crumb":"` + crumbFor(current) + `","crumbRequestField":"Jenkins-Crumb"}`))
+			case "/createItem":
+				posts++
+				if crumbIssuer {
+					cookie, err := r.Cookie("JSESSIONID")
+					if err != nil || cookie.Value != current || r.Header.Get("Jenkins-Crumb") != crumbFor(current) {
+						w.WriteHeader(http.StatusForbidden)
+						return
+					}
+				} else if r.Header.Get("Jenkins-Crumb") != "" {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+				body, _ := io.ReadAll(r.Body)
+				if len(body) == 0 {
+					w.WriteHeader(http.StatusBadRequest)
+				}
+			default:
+				w.Write([]byte("{}"))
+			}
+		}))
+		j = NewClient(srv.URL, &ClientOptions{Username: "admin", Password: "password"})
+	})
+
+	AfterEach(func() {
+		srv.Close()
+	})
+
+	createJob := func(j *Jenkins, name string) error {
+		return j.CreateJob(context.Background(), name, strings.NewReader("<project/>"))
+	}
+
+	It("should fetch a crumb once and send it with the session cookie", func() {
+		_, err := j.GetJob(context.Background(), "test")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(issued).To(Equal(0))
+
+		Expect(createJob(j, "a")).To(Succeed())
+		Expect(createJob(j, "b")).To(Succeed())
+		Expect(issued).To(Equal(1))
+		Expect(posts).To(Equal(2))
+	})
+
+	It("should refresh a crumb rejected with 403", func() {
+		Expect(createJob(j, "a")).To(Succeed())
+
+		mu.Lock()
+		session++
+		mu.Unlock()
+
+		Expect(createJob(j, "b")).To(Succeed())
+		Expect(issued).To(Equal(2))
+		Expect(posts).To(Equal(3))
+	})
+
+	It("should send no crumb when CSRF protection is disabled", func() {
+		crumbIssuer = false
+
+		Expect(createJob(j, "a")).To(Succeed())
+		Expect(createJob(j, "b")).To(Succeed())
+		Expect(issued).To(Equal(0))
+	})
+
+	It("should report rejected credentials instead of sending the request", func() {
+		unauthorized = true
+
+		err := createJob(j, "a")
+		var statusErr *StatusError
+		Expect(errors.As(err, &statusErr)).To(BeTrue())
+		Expect(statusErr.StatusCode).To(Equal(http.StatusUnauthorized))
+		Expect(statusErr.URL).To(HaveSuffix("/crumbIssuer/api/json"))
+		Expect(posts).To(Equal(0))
+	})
+
+	It("should keep crumbs apart between credentials", func() {
+		Expect(createJob(j, "a")).To(Succeed())
+
+		other := j.WithCredentials("other", "password")
+		Expect(createJob(other, "b")).To(Succeed())
+		Expect(issued).To(Equal(2))
+	})
+})
@@ -0,0 +1,215 @@
+package jenkins
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// jobTree selects the fields of jobs that Job is read from. A folder has a
+// jobs field, which is empty when the folder is.
+const jobTree = "_class,name,fullName,url,description,color,buildable,disabled,lastBuild[number],jobs[name]"
+
+// Job summarizes a job or folder.
+type Job struct {
+	// Name is the name of the job within its folder, FullName its path from
+	// the root, with folders separated by slashes.
+	Name     string
+	FullName string
+	URL      string
+	// Class is the Java class of the job, e.g.
+	// "hudson.model.FreeStyleProject".
+	Class       string
+	Description string
+	// Color summarizes the status of the last build as shown by the web UI,
+	// e.g. "blue", "red" or "disabled".
+	Color     string
+	Buildable bool
+	Disabled  bool
+	Folder    bool
+	// LastBuild is the number of the last build, or 0 if there was none.
+	LastBuild int
+}
+
+// job is a job as returned by the JSON API.
+type job struct {
+	Class       string `json:"_class"`
+	Name        string `json:"name"`
+	FullName    string `json:"fullName"`
+	URL         string `json:"url"`
+	Description string `json:"description"`
+	Color       string `json:"color"`
+	Buildable   bool   `json:"buildable"`
+	Disabled    bool   `json:"disabled"`
+	LastBuild   *struct {
+		Number int `json:"number"`
+	} `json:"lastBuild"`
+	Jobs *[]struct{} `json:"jobs"`
+}
+
+func (j *job) summary() Job {
+	s := Job{
+		Name:        j.Name,
+		FullName:    j.FullName,
+		URL:         j.URL,
+		Class:       j.Class,
+		Description: j.Description,
+		Color:       j.Color,
+		Buildable:   j.Buildable,
+		Disabled:    j.Disabled,
+		Folder:      j.Jobs != nil,
+	}
+	if j.LastBuild != nil {
+		s.LastBuild = j.LastBuild.Number
+	}
+	return s
+}
+
+// splitJob splits the full name of a job into the path of its folder and
+// its name.
+func splitJob(name string) (folder, base string) {
+	name = strings.Trim(name, "/")
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		return jobPath(name[:i]), name[i+1:]
+	}
+	return "", name
+}
+
+// CreateJob creates a job, or a folder, from its config.xml. Folders in the
+// name of the job are separated by slashes and must exist.
+func (j *Jenkins) CreateJob(ctx context.Context, name string, config io.Reader) error {
+	folder, base := splitJob(name)
+	return j.postXML(ctx, folder+"/createItem?name="+url.QueryEscape(base), config)
+}
+
+// GetJob returns a summary of a job or folder.
+func (j *Jenkins) GetJob(ctx context.Context, name string) (*Job, error) {
+	var info job
+	if err := j.getJSON(ctx, jobPath(name)+"/api/json?tree="+url.QueryEscape(jobTree), &info); err != nil {
+		return nil, err
+	}
+	summary := info.summary()
+	return &summary, nil
+}
+
+// ListJobs returns the jobs and folders in folder and, recursively, in the
+// folders below it. The root folder is "".
+func (j *Jenkins) ListJobs(ctx context.Context, folder string) ([]Job, error) {
+	var parent string
+	if strings.Trim(folder, "/") != "" {
+		parent = jobPath(folder)
+	}
+	var list struct {
+		Jobs []job `json:"jobs"`
+	}
+	if err := j.getJSON(ctx, parent+"/api/json?tree="+url.QueryEscape("jobs["+jobTree+"]"), &list); err != nil {
+		return nil, err
+	}
+	var jobs []Job
+	for _, info := range list.Jobs {
+		summary := info.summary()
+		jobs = append(jobs, summary)
+		if summary.Folder && len(*info.Jobs) > 0 {
+			nested, err := j.ListJobs(ctx, summary.FullName)
+			if err != nil {
+				return nil, err
+			}
+			jobs = append(jobs, nested...)
+		}
+	}
+	return jobs, nil
+}
+
+// GetJobConfig returns the config.xml of a job.
+func (j *Jenkins) GetJobConfig(ctx context.Context, name string) ([]byte, error) {
+	req, err := j.newRequest(ctx, "GET", jobPath(name)+"/config.xml", nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := j.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, statusError(resp)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// UpdateJob replaces the config.xml of a job.
+func (j *Jenkins) UpdateJob(ctx context.Context, name string, config io.Reader) error {
+	return j.postXML(ctx, jobPath(name)+"/config.xml", config)
+}
+
+// DeleteJob deletes a job, or a folder along with its jobs.
+func (j *Jenkins) DeleteJob(ctx context.Context, name string) error {
+	// Jenkins redirects to the parent folder once the job is deleted.
+	resp, err := j.post(ctx, jobPath(name)+"/doDelete", "", nil, http.StatusOK, http.StatusFound)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// CopyJob creates the job to as a copy of the job from. Both are full names;
+// the folder of to must exist.
+func (j *Jenkins) CopyJob(ctx context.Context, from, to string) error {
+	folder, base := splitJob(to)
+	query := url.Values{
+		"name": {base},
+		"mode": {"copy"},
+		// A leading slash makes the name absolute rather than relative to
+		// the folder of the copy.
+		"from": {"/" + strings.Trim(from, "/")},
+	}
+	resp, err := j.post(ctx, folder+"/createItem?"+query.Encode(), "", nil, http.StatusOK, http.StatusFound)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// RenameJob renames a job within its folder.
+func (j *Jenkins) RenameJob(ctx context.Context, name, newName string) error {
+	resp, err := j.post(ctx, jobPath(name)+"/confirmRename?newName="+url.QueryEscape(newName), "", nil, http.StatusOK, http.StatusFound)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// EnableJob allows a disabled job to be built again.
+func (j *Jenkins) EnableJob(ctx context.Context, name string) error {
+	return j.setEnabled(ctx, name, "/enable")
+}
+
+// DisableJob keeps a job from being built.
+func (j *Jenkins) DisableJob(ctx context.Context, name string) error {
+	return j.setEnabled(ctx, name, "/disable")
+}
+
+func (j *Jenkins) setEnabled(ctx context.Context, name, action string) error {
+	resp, err := j.post(ctx, jobPath(name)+action, "", nil, http.StatusOK, http.StatusFound)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// postXML posts an XML document to path. The document is read up front so
+// that the request can be retried with a fresh CSRF crumb.
+func (j *Jenkins) postXML(ctx context.Context, path string, config io.Reader) error {
+	xml, err := io.ReadAll(config)
+	if err != nil {
+		return err
+	}
+	resp, err := j.post(ctx, path, "application/xml", bytes.NewReader(xml), http.StatusOK)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
@@ -0,0 +1,259 @@
+package jenkins
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+const folderXML = `<com.cloudbees.hudson.plugins.folder.Folder plugin="cloudbees-folder"/>`
+
+// fakeJobs serves the job API of Jenkins for a tree of jobs kept in memory,
+// keyed by full name.
+type fakeJobs struct {
+	mu       sync.Mutex
+	configs  map[string]string
+	disabled map[string]bool
+}
+
+func (f *fakeJobs) isFolder(name string) bool {
+	return name == "" || f.configs[name] == folderXML
+}
+
+func (f *fakeJobs) children(folder string) []string {
+	var names []string
+	for name := range f.configs {
+		parent := ""
+		if i := strings.LastIndex(name, "/"); i >= 0 {
+			parent = name[:i]
+		}
+		if parent == folder {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (f *fakeJobs) describe(name string) map[string]any {
+	job := map[string]any{
+		"name":      name[strings.LastIndex(name, "/")+1:],
+		"fullName":  name,
+		"buildable": !f.disabled[name],
+		"disabled":  f.disabled[name],
+	}
+	if f.isFolder(name) {
+		job["_class"] = "com.cloudbees.hudson.plugins.folder.Folder"
+		jobs := []any{}
+		for range f.children(name) {
+			jobs = append(jobs, map[string]any{})
+		}
+		job["jobs"] = jobs
+	} else {
+		job["_class"] = "hudson.model.FreeStyleProject"
+		job["color"] = "notbuilt"
+	}
+	return job
+}
+
+func (f *fakeJobs) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	// Split /job/a/job/b/action into the full name a/b and the action.
+	var parts []string
+	rest := strings.TrimPrefix(r.URL.Path, "/")
+	for strings.HasPrefix(rest, "job/") {
+		name, after, _ := strings.Cut(strings.TrimPrefix(rest, "job/"), "/")
+		parts = append(parts, name)
+		rest = after
+	}
+	name := strings.Join(parts, "/")
+	if _, ok := f.configs[name]; name != "" && !ok {
+		http.NotFound(w, r)
+		return
+	}
+	child := func(base string) string {
+		if name == "" {
+			return base
+		}
+		return name + "/" + base
+	}
+
+	switch r.Method + " " + rest {
+	case "GET crumbIssuer/api/json":
+		http.NotFound(w, r)
+	case "GET api/json":
+		if name == "" {
+			var jobs []any
+			for _, name := range f.children("") {
+				jobs = append(jobs, f.describe(name))
+			}
+			json.NewEncoder(w).Encode(map[string]any{"jobs": jobs})
+			return
+		}
+		job := f.describe(name)
+		if f.isFolder(name) {
+			var jobs []any
+			for _, name := range f.children(name) {
+				jobs = append(jobs, f.describe(name))
+			}
+			job["jobs"] = jobs
+		}
+		json.NewEncoder(w).Encode(job)
+	case "POST createItem":
+		if !f.isFolder(name) {
+			http.NotFound(w, r)
+			return
+		}
+		target := child(r.URL.Query().Get("name"))
+		if _, ok := f.configs[target]; ok {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if r.URL.Query().Get("mode") == "copy" {
+			from, ok := f.configs[strings.TrimPrefix(r.URL.Query().Get("from"), "/")]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			f.configs[target] = from
+			return
+		}
+		Expect(r.Header.Get("Content-Type")).To(Equal("application/xml"))
+		config, _ := io.ReadAll(r.Body)
+		f.configs[target] = string(config)
+	case "GET config.xml":
+		io.WriteString(w, f.configs[name])
+	case "POST config.xml":
+		config, _ := io.ReadAll(r.Body)
+		f.configs[name] = string(config)
+	case "POST doDelete":
+		for job := range f.configs {
+			if job == name || strings.HasPrefix(job, name+"/") {
+				delete(f.configs, job)
+			}
+		}
+	case "POST confirmRename":
+		renamed := r.URL.Query().Get("newName")
+		if i := strings.LastIndex(name, "/"); i >= 0 {
+			renamed = name[:i+1] + renamed
+		}
+		for job, config := range f.configs {
+			if job == name || strings.HasPrefix(job, name+"/") {
+				delete(f.configs, job)
+				f.configs[renamed+strings.TrimPrefix(job, name)] = config
+			}
+		}
+	case "POST disable":
+		f.disabled[name] = true
+	case "POST enable":
+		delete(f.disabled, name)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+var _ = Describe("Jobs", func() {
+	var srv *httptest.Server
+	var jobs *fakeJobs
+	var j *Jenkins
+	var ctx context.Context
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		jobs = &fakeJobs{configs: map[string]string{}, disabled: map[string]bool{}}
+		srv = httptest.NewServer(jobs)
+		j = NewClient(srv.URL, nil)
+
+		Expect(j.CreateJob(ctx, "folder", strings.NewReader(folderXML))).To(Succeed())
+		Expect(j.CreateJob(ctx, "folder/nested", strings.NewReader(folderXML))).To(Succeed())
+		Expect(j.CreateJob(ctx, "folder/nested/app", strings.NewReader("<project/>"))).To(Succeed())
+		Expect(j.CreateJob(ctx, "top", strings.NewReader("<project/>"))).To(Succeed())
+	})
+
+	AfterEach(func() {
+		srv.Close()
+	})
+
+	fullNames := func(folder string) []string {
+		list, err := j.ListJobs(ctx, folder)
+		Expect(err).NotTo(HaveOccurred())
+		var names []string
+		for _, job := range list {
+			names = append(names, job.FullName)
+		}
+		return names
+	}
+
+	It("should create jobs in folders", func() {
+		Expect(jobs.configs).To(HaveKeyWithValue("folder/nested/app", "<project/>"))
+
+		job, err := j.GetJob(ctx, "folder/nested/app")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(job.Name).To(Equal("app"))
+		Expect(job.FullName).To(Equal("folder/nested/app"))
+		Expect(job.Class).To(Equal("hudson.model.FreeStyleProject"))
+		Expect(job.Buildable).To(BeTrue())
+		Expect(job.Folder).To(BeFalse())
+
+		folder, err := j.GetJob(ctx, "folder")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(folder.Folder).To(BeTrue())
+	})
+
+	It("should list jobs recursively", func() {
+		Expect(fullNames("")).To(Equal([]string{"folder", "folder/nested", "folder/nested/app", "top"}))
+		Expect(fullNames("folder/nested")).To(Equal([]string{"folder/nested/app"}))
+	})
+
+	It("should read and update the configuration of a job", func() {
+		Expect(j.UpdateJob(ctx, "folder/nested/app", strings.NewReader("<project><description>x</description></project>"))).To(Succeed())
+
+		config, err := j.GetJobConfig(ctx, "folder/nested/app")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(config)).To(ContainSubstring("<description>x</description>"))
+	})
+
+	It("should copy and rename jobs", func() {
+		Expect(j.CopyJob(ctx, "folder/nested/app", "folder/copy")).To(Succeed())
+		Expect(j.RenameJob(ctx, "folder/copy", "renamed")).To(Succeed())
+
+		Expect(fullNames("folder")).To(Equal([]string{"folder/nested", "folder/nested/app", "folder/renamed"}))
+		Expect(jobs.configs["folder/renamed"]).To(Equal("<project/>"))
+	})
+
+	It("should disable and enable jobs", func() {
+		Expect(j.DisableJob(ctx, "top")).To(Succeed())
+		job, err := j.GetJob(ctx, "top")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(job.Disabled).To(BeTrue())
+		Expect(job.Buildable).To(BeFalse())
+
+		Expect(j.EnableJob(ctx, "top")).To(Succeed())
+		job, err = j.GetJob(ctx, "top")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(job.Disabled).To(BeFalse())
+	})
+
+	It("should delete folders along with their jobs", func() {
+		Expect(j.DeleteJob(ctx, "folder")).To(Succeed())
+
+		Expect(fullNames("")).To(Equal([]string{"top"}))
+		_, err := j.GetJob(ctx, "folder/nested/app")
+		Expect(IsNotFound(err)).To(BeTrue())
+	})
+
+	It("should fail to create a job in a missing folder", func() {
+		err := j.CreateJob(ctx, "missing/app", strings.NewReader("<project/>"))
+		Expect(IsNotFound(err)).To(BeTrue())
+	})
+})
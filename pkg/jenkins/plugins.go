@@ -0,0 +1,110 @@
+package jenkins
+
+import (
+	"context"
+	"strings"
+)
+
+// Plugin describes a plugin known to the plugin manager.
+type Plugin struct {
+	ShortName string
+	LongName  string
+	Version   string
+	// Enabled plugins are loaded when Jenkins starts; Active ones are
+	// loaded now. An enabled plugin is inactive until the next restart or
+	// when it failed to load, see FailedPlugins.
+	Enabled      bool
+	Active       bool
+	Pinned       bool
+	Bundled      bool
+	HasUpdate    bool
+	Dependencies []PluginDependency
+}
+
+// PluginDependency is a plugin another plugin depends on.
+type PluginDependency struct {
+	ShortName string
+	Version   string
+	Optional  bool
+}
+
+// Plugins returns the plugins installed in Jenkins. Plugins that Jenkins
+// could not even read are left out; FailedPlugins reports them.
+func (j *Jenkins) Plugins(ctx context.Context) ([]Plugin, error) {
+	var list struct {
+		Plugins []struct {
+			ShortName    string `json:"shortName"`
+			LongName     string `json:"longName"`
+			Version      string `json:"version"`
+			Enabled      bool   `json:"enabled"`
+			Active       bool   `json:"active"`
+			Pinned       bool   `json:"pinned"`
+			Bundled      bool   `json:"bundled"`
+			HasUpdate    bool   `json:"hasUpdate"`
+			Dependencies []struct {
+				ShortName string `json:"shortName"`
+				Version   string `json:"version"`
+				Optional  bool   `json:"optional"`
+			} `json:"dependencies"`
+		} `json:"plugins"`
+	}
+	if err := j.getJSON(ctx, "/pluginManager/api/json?depth=1", &list); err != nil {
+		return nil, err
+	}
+	plugins := make([]Plugin, 0, len(list.Plugins))
+	for _, p := range list.Plugins {
+		plugin := Plugin{
+			ShortName: p.ShortName,
+			LongName:  p.LongName,
+			Version:   p.Version,
+			Enabled:   p.Enabled,
+			Active:    p.Active,
+			Pinned:    p.Pinned,
+			Bundled:   p.Bundled,
+			HasUpdate: p.HasUpdate,
+		}
+		for _, d := range p.Dependencies {
+			plugin.Dependencies = append(plugin.Dependencies, PluginDependency{
+				ShortName: d.ShortName,
+				Version:   d.Version,
+				Optional:  d.Optional,
+			})
+		}
+		plugins = append(plugins, plugin)
+	}
+	return plugins, nil
+}
+
+// FailedPlugin is a plugin that Jenkins could not load.
+type FailedPlugin struct {
+	ShortName string
+	// Cause is the exception Jenkins gave, on a single line, e.g.
+	// "java.io.IOException: Failed to load: ...".
+	Cause string
+}
+
+// failedPluginsScript prints the plugins the plugin manager failed to load,
+// one per line as the name and the cause separated by a tab.
+const failedPluginsScript = `Jenkins.instance.pluginManager.failedPlugins.each {
+	println(it.name + "\t" + String.valueOf(it.cause).replaceAll("\\s+", " "))
+}`
+
+// FailedPlugins returns the plugins that Jenkins failed to load, for example
+// because a dependency is missing or too old, including those it could not
+// read at all. The JSON API does not expose them, so this runs a script and
+// needs administrator credentials, see RunScript.
+func (j *Jenkins) FailedPlugins(ctx context.Context) ([]FailedPlugin, error) {
+	out, err := j.RunScript(ctx, failedPluginsScript)
+	if err != nil {
+		return nil, err
+	}
+	var failed []FailedPlugin
+	for _, line := range strings.Split(out, "\n") {
+		if line == "" {
+			continue
+		}
+		name, cause, _ := strings.Cut(line, "\t")
+		failed = append(failed, FailedPlugin{ShortName: name, Cause: cause})
+	}
+	return failed, nil
+}
@@ -0,0 +1,79 @@
+package jenkins
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Plugins", func() {
+	var srv *httptest.Server
+	var j *Jenkins
+
+	BeforeEach(func() {
+		mux := http.NewServeMux()
+		mux.HandleFunc("/crumbIssuer/api/json", http.NotFound)
+		mux.HandleFunc("GET /whoAmI/api/json", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"name":"admin","authenticated":true,"anonymous":false}`))
+		})
+		mux.HandleFunc("POST /scriptText", func(w http.ResponseWriter, r *http.Request) {
+			defer GinkgoRecover()
+			Expect(r.PostFormValue("script")).To(Equal(failedPluginsScript))
+			w.Write([]byte("broken\tjava.io.IOException: Failed to load: Broken (1.0) - Plugin is missing: git-client (4.0)\n" +
+				"unreadable\tjava.io.IOException: Failed to expand unreadable.jpi\n"))
+		})
+		mux.HandleFunc("/pluginManager/api/json", func(w http.ResponseWriter, r *http.Request) {
+			defer GinkgoRecover()
+			Expect(r.URL.Query().Get("depth")).To(Equal("1"))
+			w.Write([]byte(`{"plugins":[
+				{"shortName":"git","longName":"Git plugin","version":"5.2.0","enabled":true,"active":true,"pinned":true,
+				 "dependencies":[{"shortName":"git-client","version":"4.0","optional":false},{"shortName":"credentials","version":"2.6","optional":true}]},
+				{"shortName":"broken","version":"1.0","enabled":true,"active":false,"dependencies":[]},
+				{"shortName":"disabled","version":"2.0","enabled":false,"active":false,"dependencies":[]}
+			]}`))
+		})
+		srv = httptest.NewServer(mux)
+		j = NewClient(srv.URL, &ClientOptions{Username: "admin", APIToken: "token"})
+	})
+
+	AfterEach(func() {
+		srv.Close()
+	})
+
+	It("should list plugins with their state and dependencies", func() {
+		plugins, err := j.Plugins(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(plugins).To(HaveLen(3))
+		Expect(plugins[0]).To(Equal(Plugin{
+			ShortName: "git",
+			LongName:  "Git plugin",
+			Version:   "5.2.0",
+			Enabled:   true,
+			Active:    true,
+			Pinned:    true,
+			Dependencies: []PluginDependency{
+				{ShortName: "git-client", Version: "4.0"},
+				{ShortName: "credentials", Version: "2.6", Optional: true},
+			},
+		}))
+		Expect(plugins[1].Enabled).To(BeTrue())
+		Expect(plugins[1].Active).To(BeFalse())
+	})
+
+	It("should report the plugins the plugin manager failed to load", func() {
+		failed, err := j.FailedPlugins(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(failed).To(Equal([]FailedPlugin{
+			{ShortName: "broken", Cause: "java.io.IOException: Failed to load: Broken (1.0) - Plugin is missing: git-client (4.0)"},
+			{ShortName: "unreadable", Cause: "java.io.IOException: Failed to expand unreadable.jpi"},
+		}))
+	})
+
+	It("should need an administrator to report failed plugins", func() {
+		_, err := NewClient(srv.URL, nil).FailedPlugins(context.Background())
+		Expect(err).To(MatchError(ErrNotAdministrator))
+	})
+})
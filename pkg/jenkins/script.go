@@ -0,0 +1,80 @@
+package jenkins
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// ErrNotAdministrator is returned by RunScript when the client is not
+// authenticated as a user with the Overall/Administer permission.
+var ErrNotAdministrator = errors.New("not authenticated as an administrator")
+
+// ScriptError is returned by RunScript when the script threw an exception.
+type ScriptError struct {
+	// Exception is the class of the exception, e.g.
+	// "groovy.lang.MissingPropertyException".
+	Exception string
+	Message   string
+	// Output is everything the script console returned, including what
+	// the script printed before failing and the stack trace.
+	Output string
+}
+
+func (e *ScriptError) Error() string {
+	if e.Message == "" {
+		return "script failed: " + e.Exception
+	}
+	return fmt.Sprintf("script failed: %s: %s", e.Exception, e.Message)
+}
+
+// stackTrace matches the start of a Java stack trace as printed by the
+// script console: the exception class and its message, which may span
+// several lines as for compilation errors, up to the first frame.
+var stackTrace = regexp.MustCompile(`(?m)^([\w$.]+(?:Exception|Error))(?:: ?(.*(?:\n.*)*?))?\n[ \t]+at `)
+
+// RunScript runs a Groovy script in the script console of Jenkins and
+// returns what it printed. It fails with ErrNotAdministrator unless the
+// client is authenticated as an administrator, and with a *ScriptError if
+// the script threw an exception.
+func (j *Jenkins) RunScript(ctx context.Context, script string) (string, error) {
+	if j.Username == "" {
+		return "", ErrNotAdministrator
+	}
+	var me struct {
+		Name          string `json:"name"`
+		Anonymous     bool   `json:"anonymous"`
+		Authenticated bool   `json:"authenticated"`
+	}
+	if err := j.getJSON(ctx, "/whoAmI/api/json", &me); err != nil {
+		return "", err
+	}
+	if me.Anonymous || !me.Authenticated {
+		return "", fmt.Errorf("%w: Jenkins sees the client as %s", ErrNotAdministrator, me.Name)
+	}
+
+	body := url.Values{"script": {script}}.Encode()
+	resp, err := j.post(ctx, "/scriptText", "application/x-www-form-urlencoded", strings.NewReader(body), http.StatusOK)
+	if err != nil {
+		var statusErr *StatusError
+		if errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusForbidden {
+			return "", fmt.Errorf("%w: %s lacks the Overall/Administer permission: %w", ErrNotAdministrator, me.Name, err)
+		}
+		return "", err
+	}
+	defer resp.Body.Close()
+	out, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	output := string(out)
+	if m := stackTrace.FindStringSubmatch(output); m != nil {
+		return output, &ScriptError{Exception: m[1], Message: strings.TrimSpace(m[2]), Output: output}
+	}
+	return output, nil
+}
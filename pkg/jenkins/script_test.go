@@ -0,0 +1,122 @@
+package jenkins
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Script console", func() {
+	var srv *httptest.Server
+	var admins map[string]bool
+	var scripts []string
+	var j *Jenkins
+
+	BeforeEach(func() {
+		admins = map[string]bool{"admin": true}
+		scripts = nil
+		mux := http.NewServeMux()
+		mux.HandleFunc("/crumbIssuer/api/json", http.NotFound)
+		mux.HandleFunc("GET /whoAmI/api/json", func(w http.ResponseWriter, r *http.Request) {
+			if user, _, ok := r.BasicAuth(); ok {
+				fmt.Fprintf(w, `{"name":%q,"authenticated":true,"anonymous":false}`, user)
+				return
+			}
+			fmt.Fprint(w, `{"name":"anonymous","authenticated":true,"anonymous":true}`)
+		})
+		mux.HandleFunc("POST /scriptText", func(w http.ResponseWriter, r *http.Request) {
+			if user, _, _ := r.BasicAuth(); !admins[user] {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			script := r.PostFormValue("script")
+			scripts = append(scripts, script)
+			switch script {
+			case "boom":
+				fmt.Fprint(w, "before\ngroovy.lang.MissingPropertyException: No such property: boom for class: Script1\n\tat org.codehaus.groovy.runtime.ScriptBytecodeAdapter.unwrap(ScriptBytecodeAdapter.java:66)\n")
+			case "println(":
+				fmt.Fprint(w, "org.codehaus.groovy.control.MultipleCompilationErrorsException: startup failed:\n"+
+					"Script1.groovy: 1: Unexpected input: '(' @ line 1, column 8.\n"+
+					"   println(\n"+
+					"          ^\n"+
+					"\n"+
+					"1 error\n"+
+					"\n"+
+					"\tat org.codehaus.groovy.control.ErrorCollector.failIfErrors(ErrorCollector.java:309)\n")
+			case "throw":
+				fmt.Fprint(w, "java.lang.IllegalStateException: a\nb\n\tat Script1.run(Script1.groovy:1)\n")
+			case "null":
+				fmt.Fprint(w, "java.lang.NullPointerException\n\tat Script1.run(Script1.groovy:1)\n")
+			default:
+				fmt.Fprint(w, "ran "+script+"\n")
+			}
+		})
+		srv = httptest.NewServer(mux)
+		j = NewClient(srv.URL, &ClientOptions{Username: "admin", APIToken: "token"})
+	})
+
+	AfterEach(func() {
+		srv.Close()
+	})
+
+	It("should return the output of a script", func() {
+		out, err := j.RunScript(context.Background(), "println 1")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out).To(Equal("ran println 1\n"))
+		Expect(scripts).To(Equal([]string{"println 1"}))
+	})
+
+	It("should detect exceptions thrown by a script", func() {
+		out, err := j.RunScript(context.Background(), "boom")
+		Expect(out).To(HavePrefix("before\n"))
+		var scriptErr *ScriptError
+		Expect(errors.As(err, &scriptErr)).To(BeTrue())
+		Expect(scriptErr.Exception).To(Equal("groovy.lang.MissingPropertyException"))
+		Expect(scriptErr.Message).To(Equal("No such property: boom for class: Script1"))
+		Expect(scriptErr.Output).To(Equal(out))
+	})
+
+	It("should report compilation errors", func() {
+		_, err := j.RunScript(context.Background(), "println(")
+		var scriptErr *ScriptError
+		Expect(errors.As(err, &scriptErr)).To(BeTrue())
+		Expect(scriptErr.Exception).To(Equal("org.codehaus.groovy.control.MultipleCompilationErrorsException"))
+		Expect(scriptErr.Message).To(HavePrefix("startup failed:\nScript1.groovy: 1: Unexpected input: '('"))
+		Expect(scriptErr.Message).To(HaveSuffix("\n1 error"))
+	})
+
+	It("should keep messages spanning several lines", func() {
+		_, err := j.RunScript(context.Background(), "throw")
+		var scriptErr *ScriptError
+		Expect(errors.As(err, &scriptErr)).To(BeTrue())
+		Expect(scriptErr.Exception).To(Equal("java.lang.IllegalStateException"))
+		Expect(scriptErr.Message).To(Equal("a\nb"))
+	})
+
+	It("should detect exceptions without a message", func() {
+		_, err := j.RunScript(context.Background(), "null")
+		Expect(err).To(MatchError("script failed: java.lang.NullPointerException"))
+	})
+
+	It("should refuse to run scripts anonymously", func() {
+		j = NewClient(srv.URL, nil)
+
+		_, err := j.RunScript(context.Background(), "println 1")
+		Expect(err).To(MatchError(ErrNotAdministrator))
+		Expect(scripts).To(BeEmpty())
+	})
+
+	It("should report users who are not administrators", func() {
+		_, err := j.WithCredentials("developer", "password").RunScript(context.Background(), "println 1")
+		Expect(err).To(MatchError(ErrNotAdministrator))
+		var statusErr *StatusError
+		Expect(errors.As(err, &statusErr)).To(BeTrue())
+		Expect(statusErr.StatusCode).To(Equal(http.StatusForbidden))
+		Expect(err).To(MatchError(ContainSubstring("developer")))
+	})
+})
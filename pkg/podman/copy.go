@@ -0,0 +1,322 @@
+package podman
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	bcontainers "github.com/containers/podman/v5/pkg/bindings/containers"
+)
+
+// Owner is a numeric user and group as seen inside a container.
+type Owner struct {
+	UID int
+	GID int
+}
+
+// CopyOptions controls how entries copied into a container are owned.
+type CopyOptions struct {
+	// Owner, when set, becomes the owner of every copied entry. Otherwise
+	// podman assigns the entries to the container's primary user and group.
+	Owner *Owner
+}
+
+// CopyToContainer copies the file or directory tree at src on the host into
+// the directory dest in the container, keeping the base name of src and the
+// mode bits of every entry. The container does not need to be running, which
+// allows fixtures to be placed in a volume before the image's entrypoint
+// sees them.
+func (c *Client) CopyToContainer(ctx context.Context, id, src, dest string, opts *CopyOptions) error {
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(tarPath(pw, src))
+	}()
+	err := c.CopyTarToContainer(ctx, id, dest, pr, opts)
+	pr.CloseWithError(err)
+	return classify(err)
+}
+
+// WriteFileToContainer creates or replaces the file at name in the container
+// with data and the given mode. The parent directory must already exist.
+func (c *Client) WriteFileToContainer(ctx context.Context, id, name string, data []byte, mode os.FileMode, opts *CopyOptions) error {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	hdr := &tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     path.Base(name),
+		Mode:     int64(mode.Perm()),
+		Size:     int64(len(data)),
+		ModTime:  time.Now(),
+	}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return classify(err)
+	}
+	if _, err := tw.Write(data); err != nil {
+		return classify(err)
+	}
+	if err := tw.Close(); err != nil {
+		return classify(err)
+	}
+	return c.CopyTarToContainer(ctx, id, path.Dir(name), &buf, opts)
+}
+
+// CopyTarToContainer extracts the tar stream r into the directory dest in the
+// container.
+func (c *Client) CopyTarToContainer(ctx context.Context, id, dest string, r io.Reader, opts *CopyOptions) error {
+	if opts == nil {
+		opts = &CopyOptions{}
+	}
+	copyOptions := new(bcontainers.CopyOptions).WithChown(opts.Owner == nil)
+	if opts.Owner != nil {
+		r = chownTar(r, *opts.Owner)
+	}
+	copyFunc, err := bcontainers.CopyFromArchiveWithOptions(c.conn(ctx), id, dest, r, copyOptions)
+	if err != nil {
+		return classify(err)
+	}
+	if err := copyFunc(); err != nil {
+		return fmt.Errorf("copying to %s:%s: %w", id, dest, classify(err))
+	}
+	return nil
+}
+
+// CopyFromContainer copies the file or directory tree at src in the
+// container into the directory dest on the host, keeping mode bits. Entries
+// are owned by the calling user.
+func (c *Client) CopyFromContainer(ctx context.Context, id, src, dest string) error {
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(c.CopyTarFromContainer(ctx, id, src, pw))
+	}()
+	err := untarPath(pr, dest)
+	pr.CloseWithError(err)
+	return classify(err)
+}
+
+// ReadFileFromContainer returns the contents of the regular file at name in
+// the container.
+func (c *Client) ReadFileFromContainer(ctx context.Context, id, name string) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := c.CopyTarFromContainer(ctx, id, name, &buf); err != nil {
+		return nil, classify(err)
+	}
+	tr := tar.NewReader(&buf)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil, fmt.Errorf("%s:%s: no regular file in archive", id, name)
+		}
+		if err != nil {
+			return nil, classify(err)
+		}
+		if hdr.Typeflag == tar.TypeReg {
+			return io.ReadAll(tr)
+		}
+	}
+}
+
+// CopyTarFromContainer writes a tar stream of the file or directory tree at
+// src in the container to w.
+func (c *Client) CopyTarFromContainer(ctx context.Context, id, src string, w io.Writer) error {
+	copyFunc, err := bcontainers.CopyToArchive(c.conn(ctx), id, src, w)
+	if err != nil {
+		return fmt.Errorf("copying from %s:%s: %w", id, src, classify(err))
+	}
+	return copyFunc()
+}
+
+// chownTar rewrites the owner of every entry in the tar stream r.
+func chownTar(r io.Reader, owner Owner) io.Reader {
+	pr, pw := io.Pipe()
+	go func() {
+		tr := tar.NewReader(r)
+		tw := tar.NewWriter(pw)
+		for {
+			hdr, err := tr.Next()
+			if err == io.EOF {
+				pw.CloseWithError(tw.Close())
+				return
+			}
+			if err != nil {
+				pw.CloseWithError(err)
+				return
+			}
+			hdr.Uid, hdr.Gid = owner.UID, owner.GID
+			hdr.Uname, hdr.Gname = "", ""
+			if err := tw.WriteHeader(hdr); err != nil {
+				pw.CloseWithError(err)
+				return
+			}
+			if _, err := io.Copy(tw, tr); err != nil {
+				pw.CloseWithError(err)
+				return
+			}
+		}
+	}()
+	return pr
+}
+
+// tarPath writes src, and everything below it when it is a directory, to w
+// as a tar stream rooted at the base name of src.
+func tarPath(w io.Writer, src string) error {
+	tw := tar.NewWriter(w)
+	base := filepath.Dir(filepath.Clean(src))
+	err := filepath.Walk(src, func(file string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(base, file)
+		if err != nil {
+			return err
+		}
+		var link string
+		if fi.Mode()&os.ModeSymlink != 0 {
+			if link, err = os.Readlink(file); err != nil {
+				return err
+			}
+		}
+		hdr, err := tar.FileInfoHeader(fi, link)
+		if err != nil {
+			return err
+		}
+		hdr.Name = filepath.ToSlash(rel)
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if !fi.Mode().IsRegular() {
+			return nil
+		}
+		f, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(tw, f)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return tw.Close()
+}
+
+// untarPath extracts the tar stream r below the directory dest, refusing
+// entries that would escape it, whether by name, as a symlink pointing
+// outside dest or by being written through such a symlink. Directory modes
+// are applied last so that read-only directories can still be filled.
+func untarPath(r io.Reader, dest string) error {
+	if err := os.MkdirAll(dest, 0o755); err != nil {
+		return err
+	}
+	root, err := filepath.EvalSymlinks(dest)
+	if err != nil {
+		return err
+	}
+	tr := tar.NewReader(r)
+	dirModes := map[string]os.FileMode{}
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			for name, mode := range dirModes {
+				if err := os.Chmod(name, mode); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		name := filepath.Join(root, filepath.FromSlash(hdr.Name))
+		if !within(root, name) {
+			return fmt.Errorf("archive entry %q escapes %s", hdr.Name, dest)
+		}
+		if err := checkParents(root, name); err != nil {
+			return fmt.Errorf("archive entry %q: %w", hdr.Name, err)
+		}
+		mode := os.FileMode(hdr.Mode).Perm()
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(name, 0o755); err != nil {
+				return err
+			}
+			dirModes[name] = mode
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+				return err
+			}
+			// Replace rather than write through an existing symlink.
+			if fi, err := os.Lstat(name); err == nil && fi.Mode()&os.ModeSymlink != 0 {
+				if err := os.Remove(name); err != nil {
+					return err
+				}
+			}
+			f, err := os.OpenFile(name, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, mode)
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(f, tr)
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+			if err != nil {
+				return err
+			}
+			if err := os.Chmod(name, mode); err != nil {
+				return err
+			}
+		case tar.TypeSymlink:
+			target := filepath.FromSlash(hdr.Linkname)
+			if !filepath.IsAbs(target) {
+				target = filepath.Join(filepath.Dir(name), target)
+			}
+			if !within(root, filepath.Clean(target)) {
+				return fmt.Errorf("archive entry %q links to %q outside %s", hdr.Name, hdr.Linkname, dest)
+			}
+			if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+				return err
+			}
+			if err := os.Remove(name); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return err
+			}
+			if err := os.Symlink(hdr.Linkname, name); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// within reports whether name is root or below it. Both must be clean.
+func within(root, name string) bool {
+	return name == root || strings.HasPrefix(name, root+string(os.PathSeparator))
+}
+
+// checkParents fails if a directory that name would be created in resolves
+// outside root through a symlink. Only the deepest existing ancestor needs
+// resolving, as the directories below it are yet to be created.
+func checkParents(root, name string) error {
+	dir := filepath.Dir(name)
+	for within(root, dir) {
+		resolved, err := filepath.EvalSymlinks(dir)
+		if errors.Is(err, os.ErrNotExist) {
+			dir = filepath.Dir(dir)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if !within(root, resolved) {
+			return fmt.Errorf("%s resolves to %s outside %s", dir, resolved, root)
+		}
+		return nil
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+package podman
+
+import (
+	"archive/tar"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// entry is a tar entry written by archive.
+type entry struct {
+	name, link, data string
+	typeflag         byte
+}
+
+func archive(entries ...entry) *bytes.Buffer {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, e := range entries {
+		hdr := &tar.Header{Name: e.name, Linkname: e.link, Typeflag: e.typeflag, Mode: 0o644, Size: int64(len(e.data))}
+		if e.typeflag == tar.TypeDir {
+			hdr.Mode = 0o755
+		}
+		Expect(tw.WriteHeader(hdr)).To(Succeed())
+		_, err := tw.Write([]byte(e.data))
+		Expect(err).NotTo(HaveOccurred())
+	}
+	Expect(tw.Close()).To(Succeed())
+	return &buf
+}
+
+var _ = Describe("Copying", func() {
+	var dir, dest, outside string
+
+	BeforeEach(func() {
+		var err error
+		dir, err = os.MkdirTemp("", "copy-test-")
+		Expect(err).NotTo(HaveOccurred())
+		dest = filepath.Join(dir, "dest")
+		outside = filepath.Join(dir, "outside")
+		Expect(os.Mkdir(outside, 0o755)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(dir)).To(Succeed())
+	})
+
+	It("should round-trip a tree through tarPath and untarPath", func() {
+		src := filepath.Join(dir, "src")
+		Expect(os.MkdirAll(filepath.Join(src, "sub"), 0o755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(src, "sub", "run.sh"), []byte("#!/bin/sh\n"), 0o750)).To(Succeed())
+		Expect(os.Symlink("sub/run.sh", filepath.Join(src, "link"))).To(Succeed())
+
+		var buf bytes.Buffer
+		Expect(tarPath(&buf, src)).To(Succeed())
+		Expect(untarPath(&buf, dest)).To(Succeed())
+
+		data, err := os.ReadFile(filepath.Join(dest, "src", "sub", "run.sh"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(Equal("#!/bin/sh\n"))
+		fi, err := os.Stat(filepath.Join(dest, "src", "sub", "run.sh"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fi.Mode().Perm()).To(Equal(os.FileMode(0o750)))
+		link, err := os.Readlink(filepath.Join(dest, "src", "link"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(link).To(Equal("sub/run.sh"))
+	})
+
+	It("should refuse entries escaping with ../", func() {
+		err := untarPath(archive(entry{name: "../outside/file", data: "x", typeflag: tar.TypeReg}), dest)
+		Expect(err).To(MatchError(ContainSubstring("escapes")))
+		Expect(filepath.Join(outside, "file")).NotTo(BeAnExistingFile())
+	})
+
+	It("should refuse symlinks pointing outside dest", func() {
+		err := untarPath(archive(
+			entry{name: "a", link: outside, typeflag: tar.TypeSymlink},
+			entry{name: "a/passwd", data: "x", typeflag: tar.TypeReg},
+		), dest)
+		Expect(err).To(MatchError(ContainSubstring("outside")))
+		Expect(filepath.Join(outside, "passwd")).NotTo(BeAnExistingFile())
+
+		err = untarPath(archive(entry{name: "b", link: "../outside", typeflag: tar.TypeSymlink}), dest)
+		Expect(err).To(MatchError(ContainSubstring("outside")))
+	})
+
+	It("should allow symlinks within dest", func() {
+		Expect(untarPath(archive(
+			entry{name: "dir", typeflag: tar.TypeDir},
+			entry{name: "a", link: "dir", typeflag: tar.TypeSymlink},
+			entry{name: "a/file", data: "x", typeflag: tar.TypeReg},
+		), dest)).To(Succeed())
+		Expect(filepath.Join(dest, "dir", "file")).To(BeAnExistingFile())
+	})
+
+	It("should not write through an existing symlink leaving dest", func() {
+		Expect(os.MkdirAll(dest, 0o755)).To(Succeed())
+		Expect(os.Symlink(outside, filepath.Join(dest, "a"))).To(Succeed())
+
+		err := untarPath(archive(entry{name: "a/passwd", data: "x", typeflag: tar.TypeReg}), dest)
+		Expect(err).To(MatchError(ContainSubstring("outside")))
+		Expect(filepath.Join(outside, "passwd")).NotTo(BeAnExistingFile())
+
+		err = untarPath(archive(entry{name: "a/sub/passwd", data: "x", typeflag: tar.TypeReg}), dest)
+		Expect(err).To(MatchError(ContainSubstring("outside")))
+		Expect(filepath.Join(outside, "sub")).NotTo(BeADirectory())
+	})
+
+	It("should replace rather than follow a symlink to a file outside dest", func() {
+		Expect(os.MkdirAll(dest, 0o755)).To(Succeed())
+		target := filepath.Join(outside, "passwd")
+		Expect(os.WriteFile(target, []byte("root"), 0o644)).To(Succeed())
+		Expect(os.Symlink(target, filepath.Join(dest, "passwd"))).To(Succeed())
+
+		Expect(untarPath(archive(entry{name: "passwd", data: "x", typeflag: tar.TypeReg}), dest)).To(Succeed())
+		data, err := os.ReadFile(target)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(Equal("root"))
+		fi, err := os.Lstat(filepath.Join(dest, "passwd"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fi.Mode().IsRegular()).To(BeTrue())
+	})
+
+	It("should rewrite the owner of every entry with chownTar", func() {
+		r := chownTar(archive(
+			entry{name: "dir", typeflag: tar.TypeDir},
+			entry{name: "dir/file", data: "data", typeflag: tar.TypeReg},
+		), Owner{UID: 1001, GID: 0})
+
+		tr := tar.NewReader(r)
+		var names []string
+		for {
+			hdr, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+			Expect(err).NotTo(HaveOccurred())
+			Expect(hdr.Uid).To(Equal(1001))
+			Expect(hdr.Gid).To(Equal(0))
+			names = append(names, hdr.Name)
+			if hdr.Name == "dir/file" {
+				data, err := io.ReadAll(tr)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(data)).To(Equal("data"))
+			}
+		}
+		Expect(names).To(Equal([]string{"dir", "dir/file"}))
+	})
+})
@@ -0,0 +1,200 @@
+package podman
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	bcontainers "github.com/containers/podman/v5/pkg/bindings/containers"
+	"github.com/containers/podman/v5/pkg/domain/entities"
+)
+
+// DefaultDiagnosticPaths are the paths copied from Jenkins containers.
+var DefaultDiagnosticPaths = []string{
+	"/var/lib/jenkins/logs",
+	"/var/lib/jenkins/config.xml",
+}
+
+// Diagnostics collects bundles describing the state of the containers
+// carrying a set of labels. A bundle holds, for every container, its inspect
+// output, its logs, the processes running in it and a copy of selected
+// files.
+type Diagnostics struct {
+	Client *Client
+	// Labels select the containers to collect from. Every label must match.
+	Labels map[string]string
+	// Dir is where bundles are created. It defaults to the system's
+	// temporary directory.
+	Dir string
+	// Tarball writes bundles as gzip-compressed tar files instead of
+	// directories.
+	Tarball bool
+	// Paths are copied from each container when they exist. They default to
+	// DefaultDiagnosticPaths.
+	Paths []string
+}
+
+// errNoLabels is returned when Diagnostics would collect from every
+// container on the host.
+var errNoLabels = errors.New("diagnostics need labels to select containers")
+
+// Collect writes a bundle named after the current time and returns its path.
+// Information that cannot be gathered from a container, for example because
+// it is no longer running, is listed in that container's errors.txt instead
+// of failing the collection. Collect fails without Labels rather than
+// collecting from every container on the host.
+func (d *Diagnostics) Collect(ctx context.Context) (string, error) {
+	if len(d.Labels) == 0 {
+		return "", errNoLabels
+	}
+	var filters []string
+	for k, v := range d.Labels {
+		filters = append(filters, k+"="+v)
+	}
+	listOptions := new(bcontainers.ListOptions).WithAll(true).WithFilters(map[string][]string{"label": filters})
+	containers, err := bcontainers.List(d.Client.conn(ctx), listOptions)
+	if err != nil {
+		return "", err
+	}
+
+	dir := d.Dir
+	if dir == "" {
+		dir = os.TempDir()
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	bundle, err := os.MkdirTemp(dir, "diagnostics-"+time.Now().UTC().Format("20060102T150405Z")+"-")
+	if err != nil {
+		return "", err
+	}
+	for _, ctr := range containers {
+		if err := d.collectContainer(ctx, bundle, ctr); err != nil {
+			return bundle, err
+		}
+	}
+
+	if !d.Tarball {
+		return bundle, nil
+	}
+	tarball := bundle + ".tar.gz"
+	if err := writeTarball(tarball, bundle); err != nil {
+		return bundle, err
+	}
+	return tarball, os.RemoveAll(bundle)
+}
+
+// CollectEvery collects a bundle every interval until ctx is done, reporting
+// the path of each bundle, or why it could not be collected, to w. It fails
+// right away without Labels.
+func (d *Diagnostics) CollectEvery(ctx context.Context, interval time.Duration, w io.Writer) error {
+	if len(d.Labels) == 0 {
+		return errNoLabels
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			path, err := d.Collect(ctx)
+			if err != nil {
+				fmt.Fprintf(w, "collecting diagnostics: %v\n", err)
+				continue
+			}
+			fmt.Fprintf(w, "diagnostics bundle: %s\n", path)
+		}
+	}
+}
+
+// collectContainer writes the diagnostics of one container below bundle.
+// Only failures to write the bundle itself are returned.
+func (d *Diagnostics) collectContainer(ctx context.Context, bundle string, ctr entities.ListContainer) error {
+	name := ctr.ID
+	if len(name) > 12 {
+		name = name[:12]
+	}
+	if len(ctr.Names) > 0 {
+		name = ctr.Names[0]
+	}
+	dir := filepath.Join(bundle, name)
+	if err := os.MkdirAll(filepath.Join(dir, "files"), 0o755); err != nil {
+		return err
+	}
+
+	var errs []error
+	write := func(file string, data []byte, err error) error {
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", file, err))
+			if data == nil {
+				return nil
+			}
+		}
+		return os.WriteFile(filepath.Join(dir, file), data, 0o644)
+	}
+
+	data, err := bcontainers.Inspect(d.Client.conn(ctx), ctr.ID, &bcontainers.InspectOptions{})
+	var inspect []byte
+	if err == nil {
+		inspect, err = json.MarshalIndent(data, "", "\t")
+	}
+	if err := write("inspect.json", inspect, err); err != nil {
+		return err
+	}
+
+	logs, err := d.Client.ContainerLogs(ctx, ctr.ID)
+	if err := write("logs.txt", logs, err); err != nil {
+		return err
+	}
+
+	if data != nil && data.State != nil && data.State.Running {
+		res, err := d.Client.ContainerExecWithOptions(ctx, ctr.ID, []string{"ps", "-ef"}, &ExecOptions{Timeout: time.Minute})
+		var ps []byte
+		if err == nil {
+			ps = append(res.Stdout, res.Stderr...)
+		}
+		if err := write("ps.txt", ps, err); err != nil {
+			return err
+		}
+	}
+
+	paths := d.Paths
+	if paths == nil {
+		paths = DefaultDiagnosticPaths
+	}
+	for _, path := range paths {
+		if err := d.Client.CopyFromContainer(ctx, ctr.ID, path, filepath.Join(dir, "files")); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", path, err))
+		}
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return os.WriteFile(filepath.Join(dir, "errors.txt"), []byte(err.Error()+"\n"), 0o644)
+	}
+	return nil
+}
+
+// writeTarball writes the directory tree at dir to a gzip-compressed tar
+// file.
+func writeTarball(name, dir string) error {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	zw := gzip.NewWriter(f)
+	err = tarPath(zw, dir)
+	if cerr := zw.Close(); err == nil {
+		err = cerr
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
@@ -0,0 +1,23 @@
+package podman
+
+import (
+	"bytes"
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Diagnostics", func() {
+	It("should refuse to collect from every container", func() {
+		d := &Diagnostics{}
+
+		_, err := d.Collect(context.Background())
+		Expect(err).To(MatchError(errNoLabels))
+
+		var out bytes.Buffer
+		Expect(d.CollectEvery(context.Background(), time.Millisecond, &out)).To(MatchError(errNoLabels))
+		Expect(out.String()).To(BeEmpty())
+	})
+})
@@ -0,0 +1,127 @@
+package podman
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// Errors returned by the Client can be tested with errors.Is against these
+// sentinels, in addition to the underlying error.
+var (
+	// ErrNotFound means that the container, pod, image, volume or network
+	// does not exist.
+	ErrNotFound = errors.New("not found")
+	// ErrConflict means that the resource already exists or is in use.
+	ErrConflict = errors.New("conflict")
+	// ErrNotRunning means that the operation needs a running container.
+	ErrNotRunning = errors.New("not running")
+	// ErrTimeout means that the deadline of the context passed in expired.
+	ErrTimeout = errors.New("timeout")
+)
+
+// classifiedError is an error matching one of the sentinels.
+type classifiedError struct {
+	kind error
+	err  error
+}
+
+func (e *classifiedError) Error() string {
+	return e.err.Error()
+}
+
+func (e *classifiedError) Unwrap() []error {
+	return []error{e.kind, e.err}
+}
+
+// notRunningMessages identify conflicts caused by a stopped container.
+var notRunningMessages = []string{
+	"is not running",
+	"can only create exec sessions on running containers",
+	"container state improper",
+}
+
+// notFoundMessages identify missing resources in errors that carry no
+// status code, such as those of image removal reports.
+var notFoundMessages = []string{
+	"no such",
+	"not known",
+	"not found",
+}
+
+// conflictMessages identify resources that already exist or are in use,
+// such as a network with containers still attached.
+var conflictMessages = []string{
+	"already exists",
+	"in use",
+	"being used",
+}
+
+// classify wraps err so that it matches the sentinel describing it, if any.
+func classify(err error) error {
+	if err == nil {
+		return nil
+	}
+	var classified *classifiedError
+	if errors.As(err, &classified) {
+		return err
+	}
+	if kind := kindOf(err); kind != nil {
+		return &classifiedError{kind: kind, err: err}
+	}
+	return err
+}
+
+func kindOf(err error) error {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return ErrTimeout
+	}
+	msg := strings.ToLower(err.Error())
+	var coded interface{ Code() int }
+	if errors.As(err, &coded) {
+		switch coded.Code() {
+		case http.StatusNotFound:
+			return ErrNotFound
+		case http.StatusConflict:
+			if containsAny(msg, notRunningMessages) {
+				return ErrNotRunning
+			}
+			return ErrConflict
+		}
+		return nil
+	}
+	switch {
+	case containsAny(msg, notFoundMessages):
+		return ErrNotFound
+	case containsAny(msg, notRunningMessages):
+		return ErrNotRunning
+	case containsAny(msg, conflictMessages):
+		return ErrConflict
+	}
+	return nil
+}
+
+func containsAny(s string, substrs []string) bool {
+	for _, substr := range substrs {
+		if strings.Contains(s, substr) {
+			return true
+		}
+	}
+	return false
+}
+
+// IgnoreNotFound returns nil if err is ErrNotFound and err otherwise. It
+// makes stopping and removing idempotent for cleanup code that may race
+// with earlier removals:
+//
+//	_, err := client.ContainerStopAndRemove(ctx, id, 10)
+//	if err := podman.IgnoreNotFound(err); err != nil {
+//		...
+//	}
+func IgnoreNotFound(err error) error {
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	return err
+}
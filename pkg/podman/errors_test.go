@@ -0,0 +1,78 @@
+package podman
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/containers/podman/v5/pkg/errorhandling"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// apiError returns an error as the bindings return it for a failed request.
+func apiError(code int, msg string) error {
+	return &errorhandling.ErrorModel{Because: msg, Message: msg, ResponseCode: code}
+}
+
+var _ = Describe("Errors", func() {
+	cases := []struct {
+		desc string
+		err  error
+		kind error
+	}{
+		{"a 404 response", apiError(http.StatusNotFound, "no container with name or ID \"x\" found: no such container"), ErrNotFound},
+		{"a 409 response for a stopped container", apiError(http.StatusConflict, "container x is not running"), ErrNotRunning},
+		{"a 409 response for a stopped exec target", apiError(http.StatusConflict, "can only create exec sessions on running containers: container state improper"), ErrNotRunning},
+		{"a 409 response for a resource in use", apiError(http.StatusConflict, "network is being used"), ErrConflict},
+		{"a 409 response for an existing resource", apiError(http.StatusConflict, "volume with name x already exists"), ErrConflict},
+		{"a 500 response that mentions a missing resource", apiError(http.StatusInternalServerError, "image not known"), nil},
+		{"an expired deadline", fmt.Errorf("waiting: %w", context.DeadlineExceeded), ErrTimeout},
+		{"a report without a code for a missing image", errors.New("quay.io/x: image not known"), ErrNotFound},
+		{"a report without a code for a stopped container", errors.New("container x is not running"), ErrNotRunning},
+		{"a report without a code for a network in use", errors.New("network x is being used"), ErrConflict},
+		{"an unrelated error", errors.New("connection refused"), nil},
+	}
+	for _, c := range cases {
+		c := c
+		It("should classify "+c.desc, func() {
+			classified := classify(c.err)
+			if c.kind == nil {
+				Expect(kindOf(c.err)).To(BeNil())
+				Expect(classified).To(BeIdenticalTo(c.err))
+				return
+			}
+			Expect(kindOf(c.err)).To(Equal(c.kind))
+			Expect(classified).To(MatchError(c.err))
+			Expect(classified.Error()).To(Equal(c.err.Error()))
+			for _, sentinel := range []error{ErrNotFound, ErrConflict, ErrNotRunning, ErrTimeout} {
+				Expect(errors.Is(classified, sentinel)).To(Equal(sentinel == c.kind), "matching %v", sentinel)
+			}
+		})
+	}
+
+	It("should keep the underlying error reachable", func() {
+		err := classify(apiError(http.StatusNotFound, "no such volume"))
+		var model *errorhandling.ErrorModel
+		Expect(errors.As(err, &model)).To(BeTrue())
+		Expect(model.ResponseCode).To(Equal(http.StatusNotFound))
+	})
+
+	It("should not classify an error twice", func() {
+		err := classify(apiError(http.StatusNotFound, "no such pod"))
+		Expect(classify(err)).To(BeIdenticalTo(err))
+		Expect(classify(fmt.Errorf("removing: %w", err))).To(MatchError(ErrNotFound))
+		Expect(classify(nil)).To(BeNil())
+	})
+
+	It("should ignore only missing resources", func() {
+		Expect(IgnoreNotFound(nil)).To(Succeed())
+		Expect(IgnoreNotFound(classify(apiError(http.StatusNotFound, "no such container")))).To(Succeed())
+		Expect(IgnoreNotFound(fmt.Errorf("removing: %w", ErrNotFound))).To(Succeed())
+
+		conflict := classify(apiError(http.StatusConflict, "in use"))
+		Expect(IgnoreNotFound(conflict)).To(BeIdenticalTo(conflict))
+	})
+})
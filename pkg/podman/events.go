@@ -0,0 +1,164 @@
+package podman
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"time"
+
+	bcontainers "github.com/containers/podman/v5/pkg/bindings/containers"
+	bsystem "github.com/containers/podman/v5/pkg/bindings/system"
+	"github.com/containers/podman/v5/pkg/domain/entities"
+)
+
+// EventKind is the kind of a container event.
+type EventKind string
+
+const (
+	EventStart EventKind = "start"
+	// EventDie is sent when the container's main process exits.
+	EventDie EventKind = "die"
+	// EventOOM is sent just before EventDie when the kernel killed the
+	// container for exceeding its memory limit.
+	EventOOM EventKind = "oom"
+	// EventHealthStatus is sent after every healthcheck run.
+	EventHealthStatus EventKind = "health_status"
+)
+
+// podman reports the exit of a container as "died".
+var eventStatuses = map[EventKind]string{
+	EventStart:        "start",
+	EventDie:          "died",
+	EventOOM:          "died",
+	EventHealthStatus: "health_status",
+}
+
+// ContainerEvent is a container lifecycle event.
+type ContainerEvent struct {
+	Kind        EventKind
+	ContainerID string
+	Name        string
+	Image       string
+	Time        time.Time
+	// ExitCode is set for EventDie and EventOOM.
+	ExitCode int
+	// HealthStatus is set for EventHealthStatus, e.g. "healthy".
+	HealthStatus string
+	// Attributes holds the raw event attributes, including the container's
+	// labels.
+	Attributes map[string]string
+}
+
+// EventFilter selects the events returned by ContainerEvents. Empty fields
+// match everything.
+type EventFilter struct {
+	ContainerIDs []string
+	// Labels must all be present on the container with the given values.
+	Labels map[string]string
+	Kinds  []EventKind
+}
+
+// ContainerEvents subscribes to container events matching filter that happen
+// from now on. The returned channel is closed when ctx is done or the
+// service ends the stream.
+func (c *Client) ContainerEvents(ctx context.Context, filter *EventFilter) (<-chan ContainerEvent, error) {
+	filters, wanted := eventFilters(filter)
+	var oomKilled func(id string) bool
+	if wanted[EventOOM] {
+		oomKilled = func(id string) bool {
+			data, err := bcontainers.Inspect(c.conn(ctx), id, &bcontainers.InspectOptions{})
+			return err == nil && data.State != nil && data.State.OOMKilled
+		}
+	}
+
+	raw := make(chan entities.Event)
+	eventsOptions := new(bsystem.EventsOptions).WithStream(true).WithFilters(filters)
+	if err := bsystem.Events(c.conn(ctx), raw, nil, eventsOptions); err != nil {
+		return nil, classify(err)
+	}
+
+	out := make(chan ContainerEvent)
+	go func() {
+		defer close(out)
+		for e := range raw {
+			for _, ev := range convertEvent(e, oomKilled) {
+				if !wanted[ev.Kind] {
+					continue
+				}
+				select {
+				case out <- ev:
+				case <-ctx.Done():
+					// Keep draining so that the reader in the
+					// bindings can notice the cancellation.
+					for range raw {
+					}
+					return
+				}
+			}
+		}
+	}()
+	return out, nil
+}
+
+// eventFilters returns the filters the service applies for filter, along
+// with the kinds of events it selects.
+func eventFilters(filter *EventFilter) (map[string][]string, map[EventKind]bool) {
+	if filter == nil {
+		filter = &EventFilter{}
+	}
+	kinds := filter.Kinds
+	if len(kinds) == 0 {
+		kinds = []EventKind{EventStart, EventDie, EventOOM, EventHealthStatus}
+	}
+	wanted := map[EventKind]bool{}
+	statuses := map[string]bool{}
+	for _, kind := range kinds {
+		wanted[kind] = true
+		statuses[eventStatuses[kind]] = true
+	}
+
+	filters := map[string][]string{"type": {"container"}}
+	for status := range statuses {
+		filters["event"] = append(filters["event"], status)
+	}
+	sort.Strings(filters["event"])
+	if len(filter.ContainerIDs) > 0 {
+		filters["container"] = filter.ContainerIDs
+	}
+	for k, v := range filter.Labels {
+		filters["label"] = append(filters["label"], k+"="+v)
+	}
+	sort.Strings(filters["label"])
+	return filters, wanted
+}
+
+// convertEvent maps a podman event to the container events it represents.
+// podman has no OOM event, so when oomKilled is set it is asked on exit
+// whether the kernel killed the container.
+func convertEvent(e entities.Event, oomKilled func(id string) bool) []ContainerEvent {
+	ev := ContainerEvent{
+		ContainerID:  e.Actor.ID,
+		Name:         e.Actor.Attributes["name"],
+		Image:        e.Actor.Attributes["image"],
+		Time:         time.Unix(0, e.TimeNano),
+		HealthStatus: e.HealthStatus,
+		Attributes:   e.Actor.Attributes,
+	}
+	switch string(e.Action) {
+	case "start":
+		ev.Kind = EventStart
+	case "health_status":
+		ev.Kind = EventHealthStatus
+	case "died":
+		ev.Kind = EventDie
+		ev.ExitCode, _ = strconv.Atoi(e.Actor.Attributes["containerExitCode"])
+		if oomKilled != nil && oomKilled(ev.ContainerID) {
+			oom := ev
+			oom.Kind = EventOOM
+			return []ContainerEvent{oom, ev}
+		}
+	default:
+		return nil
+	}
+	return []ContainerEvent{ev}
+}
@@ -0,0 +1,108 @@
+package podman
+
+import (
+	"github.com/containers/podman/v5/pkg/domain/entities"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// containerEvent returns a podman event for container id. Callers set the
+// action.
+func containerEvent(id string, attributes map[string]string) entities.Event {
+	var e entities.Event
+	e.Type = "container"
+	e.Actor.ID = id
+	e.Actor.Attributes = attributes
+	return e
+}
+
+var _ = Describe("Events", func() {
+	Describe("filters", func() {
+		It("should select every kind of container event by default", func() {
+			filters, wanted := eventFilters(nil)
+			Expect(filters).To(Equal(map[string][]string{
+				"type":  {"container"},
+				"event": {"died", "health_status", "start"},
+			}))
+			Expect(wanted).To(Equal(map[EventKind]bool{
+				EventStart: true, EventDie: true, EventOOM: true, EventHealthStatus: true,
+			}))
+		})
+
+		It("should filter by container, label and kind", func() {
+			filters, wanted := eventFilters(&EventFilter{
+				ContainerIDs: []string{"c1", "c2"},
+				Labels:       map[string]string{"b": "2", "a": "1"},
+				Kinds:        []EventKind{EventOOM},
+			})
+			Expect(filters).To(Equal(map[string][]string{
+				"type":      {"container"},
+				"event":     {"died"},
+				"container": {"c1", "c2"},
+				"label":     {"a=1", "b=2"},
+			}))
+			Expect(wanted).To(Equal(map[EventKind]bool{EventOOM: true}))
+		})
+	})
+
+	Describe("conversion", func() {
+		It("should convert a died event", func() {
+			e := containerEvent("c1", map[string]string{"name": "jenkins", "image": "img", "containerExitCode": "137"})
+			e.Action = "died"
+
+			events := convertEvent(e, nil)
+			Expect(events).To(HaveLen(1))
+			Expect(events[0].Kind).To(Equal(EventDie))
+			Expect(events[0].ContainerID).To(Equal("c1"))
+			Expect(events[0].Name).To(Equal("jenkins"))
+			Expect(events[0].Image).To(Equal("img"))
+			Expect(events[0].ExitCode).To(Equal(137))
+		})
+
+		It("should report an OOM kill before the exit", func() {
+			e := containerEvent("c1", map[string]string{"containerExitCode": "137"})
+			e.Action = "died"
+
+			var asked []string
+			events := convertEvent(e, func(id string) bool {
+				asked = append(asked, id)
+				return true
+			})
+			Expect(asked).To(Equal([]string{"c1"}))
+			Expect(events).To(HaveLen(2))
+			Expect(events[0].Kind).To(Equal(EventOOM))
+			Expect(events[0].ExitCode).To(Equal(137))
+			Expect(events[1].Kind).To(Equal(EventDie))
+		})
+
+		It("should not report an OOM kill for a normal exit", func() {
+			e := containerEvent("c1", map[string]string{"containerExitCode": "0"})
+			e.Action = "died"
+
+			events := convertEvent(e, func(string) bool { return false })
+			Expect(events).To(HaveLen(1))
+			Expect(events[0].Kind).To(Equal(EventDie))
+		})
+
+		It("should only check for OOM kills on exit", func() {
+			e := containerEvent("c1", nil)
+			e.Action = "health_status"
+			e.HealthStatus = "healthy"
+
+			events := convertEvent(e, func(string) bool {
+				Fail("inspected a container that did not exit")
+				return false
+			})
+			Expect(events).To(HaveLen(1))
+			Expect(events[0].Kind).To(Equal(EventHealthStatus))
+			Expect(events[0].HealthStatus).To(Equal("healthy"))
+		})
+
+		It("should drop other events", func() {
+			e := containerEvent("c1", nil)
+			e.Action = "exec"
+			Expect(convertEvent(e, nil)).To(BeEmpty())
+		})
+	})
+})
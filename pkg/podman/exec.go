@@ -0,0 +1,106 @@
+package podman
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"time"
+
+	"github.com/containers/podman/v5/pkg/api/handlers"
+	bcontainers "github.com/containers/podman/v5/pkg/bindings/containers"
+)
+
+// ExecOptions configures a command run by ContainerExecWithOptions. The zero
+// value runs the command as the container's user in its working directory.
+type ExecOptions struct {
+	// Env holds additional KEY=value pairs for the command.
+	Env []string
+	// User overrides the user the command runs as, e.g. "1001" or "0:0".
+	User string
+	// WorkDir overrides the working directory of the command.
+	WorkDir string
+	// Tty allocates a terminal. Stdout and stderr are not separated when a
+	// terminal is used, so all output ends up in ExecResult.Stdout.
+	Tty bool
+	// Stdin, when set, is copied to the command's standard input, which is
+	// closed once Stdin returns EOF.
+	Stdin io.Reader
+	// Timeout bounds the call when positive. The command itself is not
+	// killed when the timeout expires.
+	Timeout time.Duration
+}
+
+// ExecResult holds the exit code and output of a finished command.
+type ExecResult struct {
+	ExitCode int
+	Stdout   []byte
+	Stderr   []byte
+}
+
+// ContainerExec runs cmd in a container and returns its exit code and stdout.
+func (c *Client) ContainerExec(ctx context.Context, id string, cmd []string) (int, []byte, error) {
+	res, err := c.ContainerExecWithOptions(ctx, id, cmd, nil)
+	if err != nil {
+		return 0, nil, err
+	}
+	return res.ExitCode, res.Stdout, nil
+}
+
+// ContainerExecWithOptions runs cmd in a container and waits for it to exit.
+// Stdout and stderr are collected while the command runs, so output larger
+// than a pipe buffer cannot stall it.
+func (c *Client) ContainerExecWithOptions(ctx context.Context, id string, cmd []string, opts *ExecOptions) (*ExecResult, error) {
+	if opts == nil {
+		opts = &ExecOptions{}
+	}
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	createConfig := new(handlers.ExecCreateConfig)
+	createConfig.Cmd = cmd
+	createConfig.Env = opts.Env
+	createConfig.User = opts.User
+	createConfig.WorkingDir = opts.WorkDir
+	createConfig.Tty = opts.Tty
+	createConfig.AttachStdin = opts.Stdin != nil
+	createConfig.AttachStdout = true
+	createConfig.AttachStderr = true
+	sessionID, err := bcontainers.ExecCreate(c.conn(ctx), id, createConfig)
+	if err != nil {
+		return nil, classify(err)
+	}
+
+	// The buffers are only written by the attach call and only read once
+	// it has returned.
+	var stdout, stderr bytes.Buffer
+	attachOptions := new(bcontainers.ExecStartAndAttachOptions).
+		WithOutputStream(&stdout).
+		WithErrorStream(&stderr).
+		WithAttachOutput(true).
+		WithAttachError(true).
+		WithAttachInput(opts.Stdin != nil)
+	if opts.Stdin != nil {
+		attachOptions.WithInputStream(*bufio.NewReader(opts.Stdin))
+	}
+	err = runCtx(ctx, func() error {
+		return bcontainers.ExecStartAndAttach(c.conn(ctx), sessionID, attachOptions)
+	})
+	if err != nil {
+		return nil, classify(err)
+	}
+
+	inspect, err := bcontainers.ExecInspect(c.conn(ctx), sessionID, &bcontainers.ExecInspectOptions{})
+	if err != nil {
+		return nil, classify(err)
+	}
+
+	return &ExecResult{
+		ExitCode: inspect.ExitCode,
+		Stdout:   stdout.Bytes(),
+		Stderr:   stderr.Bytes(),
+	}, nil
+}
@@ -0,0 +1,200 @@
+package fake
+
+import (
+	"archive/tar"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/openshift/jenkins/pkg/podman"
+)
+
+// The copy calls keep the regular files of a container in Container.Files.
+// Directories exist implicitly and other kinds of entries are dropped.
+
+func (r *Runtime) CopyToContainer(ctx context.Context, id, src, dest string, opts *podman.CopyOptions) error {
+	if err := r.call("CopyToContainer", id, src, dest, opts); err != nil {
+		return err
+	}
+	files := map[string]File{}
+	err := filepath.WalkDir(src, func(name string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(filepath.Dir(src), name)
+		if err != nil {
+			return err
+		}
+		files[containerPath(dest, filepath.ToSlash(rel))] = File{Data: data, Mode: info.Mode().Perm()}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return r.store(id, files, opts)
+}
+
+func (r *Runtime) WriteFileToContainer(ctx context.Context, id, name string, data []byte, mode os.FileMode, opts *podman.CopyOptions) error {
+	if err := r.call("WriteFileToContainer", id, name, data, mode, opts); err != nil {
+		return err
+	}
+	return r.store(id, map[string]File{containerPath(name): {Data: slices.Clone(data), Mode: mode.Perm()}}, opts)
+}
+
+func (r *Runtime) CopyTarToContainer(ctx context.Context, id, dest string, rd io.Reader, opts *podman.CopyOptions) error {
+	if err := r.call("CopyTarToContainer", id, dest, opts); err != nil {
+		return err
+	}
+	files := map[string]File{}
+	tr := tar.NewReader(rd)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return err
+		}
+		files[containerPath(dest, hdr.Name)] = File{Data: data, Mode: os.FileMode(hdr.Mode).Perm()}
+	}
+	return r.store(id, files, opts)
+}
+
+// store adds files to a container.
+func (r *Runtime) store(id string, files map[string]File, opts *podman.CopyOptions) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ctr, ok := r.containers[id]
+	if !ok {
+		return noSuchContainer(id)
+	}
+	for name, file := range files {
+		if opts != nil && opts.Owner != nil {
+			owner := *opts.Owner
+			file.Owner = &owner
+		}
+		ctr.Files[name] = file
+	}
+	return nil
+}
+
+func (r *Runtime) CopyFromContainer(ctx context.Context, id, src, dest string) error {
+	if err := r.call("CopyFromContainer", id, src, dest); err != nil {
+		return err
+	}
+	files, err := r.load(id, src)
+	if err != nil {
+		return err
+	}
+	for name, file := range files {
+		target := filepath.Join(dest, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(target, file.Data, file.Mode); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Runtime) ReadFileFromContainer(ctx context.Context, id, name string) ([]byte, error) {
+	if err := r.call("ReadFileFromContainer", id, name); err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ctr, ok := r.containers[id]
+	if !ok {
+		return nil, noSuchContainer(id)
+	}
+	file, ok := ctr.Files[containerPath(name)]
+	if !ok {
+		return nil, noSuchFile(id, name)
+	}
+	return slices.Clone(file.Data), nil
+}
+
+func (r *Runtime) CopyTarFromContainer(ctx context.Context, id, src string, w io.Writer) error {
+	if err := r.call("CopyTarFromContainer", id, src); err != nil {
+		return err
+	}
+	files, err := r.load(id, src)
+	if err != nil {
+		return err
+	}
+	tw := tar.NewWriter(w)
+	for _, name := range slices.Sorted(maps.Keys(files)) {
+		file := files[name]
+		hdr := &tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     name,
+			Mode:     int64(file.Mode),
+			Size:     int64(len(file.Data)),
+			ModTime:  time.Now(),
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if _, err := tw.Write(file.Data); err != nil {
+			return err
+		}
+	}
+	return tw.Close()
+}
+
+// load returns the files of a container at or below src, keyed by their path
+// relative to the parent of src.
+func (r *Runtime) load(id, src string) (map[string]File, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ctr, ok := r.containers[id]
+	if !ok {
+		return nil, noSuchContainer(id)
+	}
+	src = containerPath(src)
+	parent := path.Dir(src)
+	files := map[string]File{}
+	for name, file := range ctr.Files {
+		if name == src || src == "/" || strings.HasPrefix(name, src+"/") {
+			rel := strings.TrimPrefix(strings.TrimPrefix(name, parent), "/")
+			files[rel] = file
+		}
+	}
+	if len(files) == 0 {
+		return nil, noSuchFile(id, src)
+	}
+	return files, nil
+}
+
+// containerPath joins elem into an absolute, clean path.
+func containerPath(elem ...string) string {
+	return path.Join(append([]string{"/"}, elem...)...)
+}
+
+func noSuchFile(id, name string) error {
+	return fmt.Errorf("%s:%s: no such file or directory: %w", id, name, podman.ErrNotFound)
+}
@@ -0,0 +1,1186 @@
+// Package fake provides an in-memory podman.Runtime for unit tests of code
+// that drives containers, such as package jenkins.
+package fake
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	nettypes "github.com/containers/common/libnetwork/types"
+	"github.com/containers/podman/v5/libpod/define"
+	"github.com/containers/podman/v5/pkg/domain/entities"
+	"github.com/containers/podman/v5/pkg/domain/entities/reports"
+	"github.com/containers/podman/v5/pkg/specgen"
+
+	"github.com/openshift/jenkins/pkg/podman"
+)
+
+// Call is a recorded call to the Runtime. Args holds the arguments after the
+// context.
+type Call struct {
+	Method string
+	Args   []any
+}
+
+// Container is the state the fake keeps for a created container.
+type Container struct {
+	ID      string
+	Spec    *specgen.SpecGenerator
+	Running bool
+	// Started is set once the container has been started, so that a
+	// stopped container is reported as exited rather than created.
+	Started bool
+	// ExitCode is returned by ContainerWait once the container stopped.
+	ExitCode int32
+	// Logs is the output returned by the log calls.
+	Logs []byte
+	// HostPorts maps each published port to its host:port address.
+	HostPorts map[uint16]string
+	// Networks maps each attached network to the container's address.
+	Networks map[string]string
+	// Health is the health status reported while the container runs. When
+	// it is empty, a running container with a healthcheck is healthy.
+	Health string
+	// Stats is the sample returned by the stats calls while the container
+	// runs.
+	Stats podman.Stats
+	// Files maps absolute paths in the container to the regular files
+	// copied into it.
+	Files map[string]File
+}
+
+// File is a regular file in a container.
+type File struct {
+	Data []byte
+	Mode os.FileMode
+	// Owner is the owner requested when the file was copied in, if any.
+	Owner *podman.Owner
+}
+
+// Pod is the state the fake keeps for a created pod.
+type Pod struct {
+	ID     string
+	Name   string
+	Labels map[string]string
+	// HostPorts maps each published port to its host:port address.
+	HostPorts map[uint16]string
+	// Containers holds the IDs of the member containers.
+	Containers []string
+}
+
+// Runtime is an in-memory podman.Runtime. Containers, pods, volumes, images
+// and networks behave like their podman counterparts as far as their names and
+// state go; nothing is run. Responses can be scripted with FailNext,
+// QueueExec, AppendLogs, SetHealth and Emit, and all calls are recorded.
+type Runtime struct {
+	// HostPorts, when it has an entry for a published container port, is
+	// the address returned by ContainerHostPort for it. Other published
+	// ports get a made-up loopback address.
+	HostPorts map[uint16]string
+	// OnStart, when set, is called after a container has been started.
+	OnStart func(id string)
+
+	mu         sync.Mutex
+	calls      []Call
+	failures   map[string][]error
+	execs      []podman.ExecResult
+	containers map[string]*Container
+	pods       map[string]*Pod
+	volumes    map[string]*volume
+	images     map[string]*podman.ImageSummary
+	networks   map[string]string
+	subs       []*subscriber
+	// changed is closed and replaced whenever container state or logs
+	// change, waking up blocked waits.
+	changed chan struct{}
+	next    int
+}
+
+var _ podman.Runtime = (*Runtime)(nil)
+
+// New returns an empty Runtime.
+func New() *Runtime {
+	return &Runtime{
+		failures:   map[string][]error{},
+		containers: map[string]*Container{},
+		pods:       map[string]*Pod{},
+		volumes:    map[string]*volume{},
+		images:     map[string]*podman.ImageSummary{},
+		networks:   map[string]string{},
+		changed:    make(chan struct{}),
+	}
+}
+
+// Calls returns every call made so far, in order.
+func (r *Runtime) Calls() []Call {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Call(nil), r.calls...)
+}
+
+// CallsTo returns the calls made so far to method.
+func (r *Runtime) CallsTo(method string) []Call {
+	var calls []Call
+	for _, call := range r.Calls() {
+		if call.Method == method {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+// FailNext makes the next call to method return err without any effect.
+// Several failures for one method are returned in the order they were added.
+func (r *Runtime) FailNext(method string, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.failures[method] = append(r.failures[method], err)
+}
+
+// QueueExec queues the result of the next exec in a running container.
+// Without a queued result commands exit 0 without output.
+func (r *Runtime) QueueExec(res podman.ExecResult) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.execs = append(r.execs, res)
+}
+
+// AddImage makes an image known under name, as if it had been pulled.
+func (r *Runtime) AddImage(name string, image *podman.ImageSummary) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.images[name] = image
+}
+
+// AppendLogs adds data to the log output of a container.
+func (r *Runtime) AppendLogs(id string, data []byte) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ctr, ok := r.containers[id]
+	if !ok {
+		return noSuchContainer(id)
+	}
+	ctr.Logs = append(ctr.Logs, data...)
+	r.notify()
+	return nil
+}
+
+// Container returns a copy of the state of a container, or nil if there is
+// no container with that ID.
+func (r *Runtime) Container(id string) *Container {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ctr, ok := r.containers[id]
+	if !ok {
+		return nil
+	}
+	cp := *ctr
+	cp.Logs = slices.Clone(ctr.Logs)
+	cp.HostPorts = maps.Clone(ctr.HostPorts)
+	cp.Networks = maps.Clone(ctr.Networks)
+	cp.Files = maps.Clone(ctr.Files)
+	return &cp
+}
+
+// SetHealth changes the health status a container reports.
+func (r *Runtime) SetHealth(id, status string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ctr, ok := r.containers[id]
+	if !ok {
+		return noSuchContainer(id)
+	}
+	ctr.Health = status
+	r.notify()
+	return nil
+}
+
+// Emit sends ev to every subscriber whose filter matches it. EventDie also
+// stops the container with ev.ExitCode.
+func (r *Runtime) Emit(ev podman.ContainerEvent) {
+	r.mu.Lock()
+	ctr, ok := r.containers[ev.ContainerID]
+	if ok && ev.Kind == podman.EventDie {
+		ctr.Running = false
+		ctr.ExitCode = int32(ev.ExitCode)
+		r.notify()
+	}
+	var labels map[string]string
+	if ok {
+		if ev.Name == "" {
+			ev.Name = ctr.Spec.Name
+		}
+		if ev.Image == "" {
+			ev.Image = ctr.Spec.Image
+		}
+		labels = ctr.Spec.Labels
+	}
+	subs := append([]*subscriber(nil), r.subs...)
+	r.mu.Unlock()
+
+	for _, sub := range subs {
+		if sub.matches(ev, labels) {
+			sub.send(ev)
+		}
+	}
+}
+
+// call records a call and returns the failure scripted for it, if any.
+func (r *Runtime) call(method string, args ...any) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, Call{Method: method, Args: args})
+	if errs := r.failures[method]; len(errs) > 0 {
+		r.failures[method] = errs[1:]
+		return errs[0]
+	}
+	return nil
+}
+
+// notify wakes up waiters. r.mu must be held.
+func (r *Runtime) notify() {
+	close(r.changed)
+	r.changed = make(chan struct{})
+}
+
+// waitUntil blocks until cond, which is called with r.mu held, returns true
+// or ctx is done.
+func (r *Runtime) waitUntil(ctx context.Context, cond func() (bool, error)) error {
+	for {
+		r.mu.Lock()
+		done, err := cond()
+		changed := r.changed
+		r.mu.Unlock()
+		if done || err != nil {
+			return err
+		}
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (r *Runtime) nextID(kind string) string {
+	r.next++
+	return fmt.Sprintf("fake-%s-%d", kind, r.next)
+}
+
+func noSuchContainer(id string) error {
+	return fmt.Errorf("no container with name or ID %q found: %w", id, podman.ErrNotFound)
+}
+
+func (r *Runtime) ContainerCreate(ctx context.Context, config *specgen.SpecGenerator) (string, error) {
+	if err := r.call("ContainerCreate", config); err != nil {
+		return "", err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ctr := &Container{
+		ID:        r.nextID("container"),
+		Spec:      config,
+		HostPorts: map[uint16]string{},
+		Networks:  map[string]string{},
+		Files:     map[string]File{},
+	}
+	r.publish(ctr.HostPorts, config.PortMappings)
+	for network := range config.Networks {
+		if _, ok := r.networks[network]; !ok {
+			return "", noSuchNetwork(network)
+		}
+		ctr.Networks[network] = fmt.Sprintf("10.89.0.%d", r.next+1)
+	}
+	if config.Pod != "" {
+		pod := r.lookupPod(config.Pod)
+		if pod == nil {
+			return "", noSuchPod(config.Pod)
+		}
+		pod.Containers = append(pod.Containers, ctr.ID)
+	}
+	r.containers[ctr.ID] = ctr
+	return ctr.ID, nil
+}
+
+// publish assigns host addresses to port mappings. r.mu must be held.
+func (r *Runtime) publish(addrs map[uint16]string, mappings []nettypes.PortMapping) {
+	for _, mapping := range mappings {
+		addr, ok := r.HostPorts[mapping.ContainerPort]
+		if !ok {
+			addr = fmt.Sprintf("127.0.0.1:%d", 32768+r.next)
+		}
+		addrs[mapping.ContainerPort] = addr
+	}
+}
+
+func (r *Runtime) PodCreate(ctx context.Context, name string, opts *podman.PodOptions) (string, error) {
+	if err := r.call("PodCreate", name, opts); err != nil {
+		return "", err
+	}
+	if opts == nil {
+		opts = &podman.PodOptions{}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if name != "" && r.lookupPod(name) != nil {
+		return "", fmt.Errorf("%s: pod already exists: %w", name, podman.ErrConflict)
+	}
+	pod := &Pod{
+		ID:        r.nextID("pod"),
+		Name:      name,
+		Labels:    opts.Labels,
+		HostPorts: map[uint16]string{},
+	}
+	var mappings []nettypes.PortMapping
+	for _, port := range opts.Ports {
+		mappings = append(mappings, nettypes.PortMapping{ContainerPort: port, Protocol: "tcp"})
+	}
+	r.publish(pod.HostPorts, mappings)
+	r.pods[pod.ID] = pod
+	return pod.ID, nil
+}
+
+func (r *Runtime) PodStart(ctx context.Context, nameOrID string) error {
+	if err := r.call("PodStart", nameOrID); err != nil {
+		return err
+	}
+	ids, err := r.podContainers(nameOrID)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if err := r.ContainerStart(ctx, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Runtime) PodStop(ctx context.Context, nameOrID string, timeout int) error {
+	if err := r.call("PodStop", nameOrID, timeout); err != nil {
+		return err
+	}
+	ids, err := r.podContainers(nameOrID)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if err := r.ContainerStop(ctx, id, timeout); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Runtime) PodRemove(ctx context.Context, nameOrID string) error {
+	if err := r.call("PodRemove", nameOrID); err != nil {
+		return err
+	}
+	ids, err := r.podContainers(nameOrID)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if _, err := r.ContainerStopAndRemove(ctx, id, 10); err != nil {
+			return err
+		}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if pod := r.lookupPod(nameOrID); pod != nil {
+		delete(r.pods, pod.ID)
+	}
+	return nil
+}
+
+func (r *Runtime) PodHostPort(ctx context.Context, nameOrID string, port uint16) (string, error) {
+	if err := r.call("PodHostPort", nameOrID, port); err != nil {
+		return "", err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	pod := r.lookupPod(nameOrID)
+	if pod == nil {
+		return "", noSuchPod(nameOrID)
+	}
+	addr, ok := pod.HostPorts[port]
+	if !ok {
+		return "", fmt.Errorf("pod %s does not publish port %d", nameOrID, port)
+	}
+	return addr, nil
+}
+
+// Pod returns a copy of the state of a pod, or nil if there is no pod with
+// that name or ID.
+func (r *Runtime) Pod(nameOrID string) *Pod {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	pod := r.lookupPod(nameOrID)
+	if pod == nil {
+		return nil
+	}
+	cp := *pod
+	cp.HostPorts = maps.Clone(pod.HostPorts)
+	cp.Containers = slices.Clone(pod.Containers)
+	return &cp
+}
+
+// podContainers returns the IDs of the containers of a pod that still exist.
+func (r *Runtime) podContainers(nameOrID string) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	pod := r.lookupPod(nameOrID)
+	if pod == nil {
+		return nil, noSuchPod(nameOrID)
+	}
+	var ids []string
+	for _, id := range pod.Containers {
+		if _, ok := r.containers[id]; ok {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+// lookupPod finds a pod by name or ID. r.mu must be held.
+func (r *Runtime) lookupPod(nameOrID string) *Pod {
+	if pod, ok := r.pods[nameOrID]; ok {
+		return pod
+	}
+	for _, pod := range r.pods {
+		if pod.Name == nameOrID {
+			return pod
+		}
+	}
+	return nil
+}
+
+func noSuchImage(nameOrID string) error {
+	return fmt.Errorf("%s: image not known: %w", nameOrID, podman.ErrNotFound)
+}
+
+func noSuchNetwork(nameOrID string) error {
+	return fmt.Errorf("unable to find network with name or ID %s: %w", nameOrID, podman.ErrNotFound)
+}
+
+func noSuchPod(nameOrID string) error {
+	return fmt.Errorf("no pod with name or ID %s found: %w", nameOrID, podman.ErrNotFound)
+}
+
+func (r *Runtime) ContainerStart(ctx context.Context, id string) error {
+	if err := r.call("ContainerStart", id); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	ctr, ok := r.containers[id]
+	if ok {
+		ctr.Running = true
+		ctr.Started = true
+		r.notify()
+	}
+	r.mu.Unlock()
+	if !ok {
+		return noSuchContainer(id)
+	}
+	r.Emit(podman.ContainerEvent{Kind: podman.EventStart, ContainerID: id})
+	if r.OnStart != nil {
+		r.OnStart(id)
+	}
+	return nil
+}
+
+func (r *Runtime) ContainerStop(ctx context.Context, id string, timeout int) error {
+	if err := r.call("ContainerStop", id, timeout); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	ctr, ok := r.containers[id]
+	running := ok && ctr.Running
+	var exitCode int32
+	if ok {
+		exitCode = ctr.ExitCode
+	}
+	r.mu.Unlock()
+	if !ok {
+		return noSuchContainer(id)
+	}
+	if running {
+		r.Emit(podman.ContainerEvent{Kind: podman.EventDie, ContainerID: id, ExitCode: int(exitCode)})
+	}
+	return nil
+}
+
+func (r *Runtime) ContainerRemove(ctx context.Context, id string) ([]*reports.RmReport, error) {
+	if err := r.call("ContainerRemove", id); err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.containers[id]; !ok {
+		return nil, noSuchContainer(id)
+	}
+	delete(r.containers, id)
+	r.notify()
+	return []*reports.RmReport{{Id: id, RawInput: id}}, nil
+}
+
+func (r *Runtime) ContainerStopAndRemove(ctx context.Context, id string, timeout int) ([]*reports.RmReport, error) {
+	if err := r.call("ContainerStopAndRemove", id, timeout); err != nil {
+		return nil, err
+	}
+	if err := r.ContainerStop(ctx, id, timeout); err != nil {
+		return nil, err
+	}
+	return r.ContainerRemove(ctx, id)
+}
+
+func (r *Runtime) ContainerWait(ctx context.Context, id string) (int32, error) {
+	if err := r.call("ContainerWait", id); err != nil {
+		return -1, err
+	}
+	var exitCode int32
+	err := r.waitUntil(ctx, func() (bool, error) {
+		ctr, ok := r.containers[id]
+		if !ok {
+			return false, noSuchContainer(id)
+		}
+		exitCode = ctr.ExitCode
+		return !ctr.Running, nil
+	})
+	if err != nil {
+		return -1, err
+	}
+	return exitCode, nil
+}
+
+func (r *Runtime) ContainerHostPort(ctx context.Context, id string, port uint16) (string, error) {
+	if err := r.call("ContainerHostPort", id, port); err != nil {
+		return "", err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ctr, ok := r.containers[id]
+	if !ok {
+		return "", noSuchContainer(id)
+	}
+	addr, ok := ctr.HostPorts[port]
+	if !ok || !ctr.Running {
+		return "", fmt.Errorf("container %s does not publish port %d", id, port)
+	}
+	return addr, nil
+}
+
+func (r *Runtime) ContainerNetworkIP(ctx context.Context, id, network string) (string, error) {
+	if err := r.call("ContainerNetworkIP", id, network); err != nil {
+		return "", err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ctr, ok := r.containers[id]
+	if !ok {
+		return "", noSuchContainer(id)
+	}
+	ip, ok := ctr.Networks[network]
+	if !ok || !ctr.Running {
+		return "", fmt.Errorf("container %s has no address on network %s", id, network)
+	}
+	return ip, nil
+}
+
+// ContainerInspect returns the address of a running container on the
+// default "podman" network, if it is attached to it.
+func (r *Runtime) ContainerInspect(ctx context.Context, id string) (string, error) {
+	if err := r.call("ContainerInspect", id); err != nil {
+		return "", err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ctr, ok := r.containers[id]
+	if !ok {
+		return "", noSuchContainer(id)
+	}
+	if !ctr.Running {
+		return "", nil
+	}
+	return ctr.Networks["podman"], nil
+}
+
+func (r *Runtime) ContainerHostPorts(ctx context.Context, id string) (map[uint16]string, error) {
+	if err := r.call("ContainerHostPorts", id); err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ctr, ok := r.containers[id]
+	if !ok {
+		return nil, noSuchContainer(id)
+	}
+	if !ctr.Running {
+		return map[uint16]string{}, nil
+	}
+	return maps.Clone(ctr.HostPorts), nil
+}
+
+func (r *Runtime) ContainerNetworkIPs(ctx context.Context, id string) (map[string]string, error) {
+	if err := r.call("ContainerNetworkIPs", id); err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ctr, ok := r.containers[id]
+	if !ok {
+		return nil, noSuchContainer(id)
+	}
+	if !ctr.Running {
+		return map[string]string{}, nil
+	}
+	return maps.Clone(ctr.Networks), nil
+}
+
+// WaitFor blocks until the inspect data of a container meets cond. The data
+// only carries the name, image and state of the container.
+func (r *Runtime) WaitFor(ctx context.Context, id string, cond podman.Condition) (*define.InspectContainerData, error) {
+	if err := r.call("WaitFor", id, cond); err != nil {
+		return nil, err
+	}
+	var data *define.InspectContainerData
+	err := r.waitUntil(ctx, func() (bool, error) {
+		ctr, ok := r.containers[id]
+		if !ok {
+			return false, noSuchContainer(id)
+		}
+		data = inspect(ctr)
+		return cond.Met(data)
+	})
+	if err != nil && err == ctx.Err() {
+		return data, fmt.Errorf("waiting for container %s to be %s: %w", id, cond, err)
+	}
+	return data, err
+}
+
+// inspect returns the inspect data of a container. r.mu must be held.
+func inspect(ctr *Container) *define.InspectContainerData {
+	state := &define.InspectContainerState{
+		Status:   "created",
+		Running:  ctr.Running,
+		ExitCode: ctr.ExitCode,
+	}
+	switch {
+	case ctr.Running:
+		state.Status = "running"
+	case ctr.Started:
+		state.Status = "exited"
+	}
+	health := ctr.Health
+	if health == "" && ctr.Running && ctr.Spec.HealthConfig != nil {
+		health = define.HealthCheckHealthy
+	}
+	if health != "" {
+		state.Health = &define.HealthCheckResults{Status: health}
+	}
+	return &define.InspectContainerData{
+		ID:        ctr.ID,
+		Name:      ctr.Spec.Name,
+		ImageName: ctr.Spec.Image,
+		State:     state,
+	}
+}
+
+// ContainerStats returns the Stats of a running container, taken now.
+func (r *Runtime) ContainerStats(ctx context.Context, id string) (*podman.Stats, error) {
+	if err := r.call("ContainerStats", id); err != nil {
+		return nil, err
+	}
+	return r.sample(id)
+}
+
+// ContainerStatsSeries samples the Stats of a running container every
+// interval until ctx is done.
+func (r *Runtime) ContainerStatsSeries(ctx context.Context, id string, interval time.Duration) (podman.StatsSeries, error) {
+	if err := r.call("ContainerStatsSeries", id, interval); err != nil {
+		return nil, err
+	}
+	if interval <= 0 {
+		interval = time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var series podman.StatsSeries
+	for {
+		stats, err := r.sample(id)
+		if err != nil {
+			return series, err
+		}
+		series = append(series, *stats)
+		select {
+		case <-ctx.Done():
+			return series, nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *Runtime) sample(id string) (*podman.Stats, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ctr, ok := r.containers[id]
+	if !ok {
+		return nil, noSuchContainer(id)
+	}
+	if !ctr.Running {
+		return nil, fmt.Errorf("container %s: %w", id, podman.ErrNotRunning)
+	}
+	stats := ctr.Stats
+	stats.Time = time.Now()
+	return &stats, nil
+}
+
+func (r *Runtime) ContainerEvents(ctx context.Context, filter *podman.EventFilter) (<-chan podman.ContainerEvent, error) {
+	if err := r.call("ContainerEvents", filter); err != nil {
+		return nil, err
+	}
+	if filter == nil {
+		filter = &podman.EventFilter{}
+	}
+	sub := &subscriber{ctx: ctx, filter: *filter, ch: make(chan podman.ContainerEvent, 16)}
+	r.mu.Lock()
+	r.subs = append(r.subs, sub)
+	r.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		r.mu.Lock()
+		for i, s := range r.subs {
+			if s == sub {
+				r.subs = append(r.subs[:i], r.subs[i+1:]...)
+				break
+			}
+		}
+		r.mu.Unlock()
+		sub.mu.Lock()
+		sub.closed = true
+		close(sub.ch)
+		sub.mu.Unlock()
+	}()
+	return sub.ch, nil
+}
+
+func (r *Runtime) ContainerExec(ctx context.Context, id string, cmd []string) (int, []byte, error) {
+	res, err := r.ContainerExecWithOptions(ctx, id, cmd, nil)
+	if err != nil {
+		return -1, nil, err
+	}
+	return res.ExitCode, append(res.Stdout, res.Stderr...), nil
+}
+
+func (r *Runtime) ContainerExecWithOptions(ctx context.Context, id string, cmd []string, opts *podman.ExecOptions) (*podman.ExecResult, error) {
+	if err := r.call("ContainerExecWithOptions", id, cmd, opts); err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ctr, ok := r.containers[id]
+	if !ok {
+		return nil, noSuchContainer(id)
+	}
+	if !ctr.Running {
+		return nil, fmt.Errorf("container %s: %w", id, podman.ErrNotRunning)
+	}
+	res := &podman.ExecResult{}
+	if len(r.execs) > 0 {
+		*res = r.execs[0]
+		r.execs = r.execs[1:]
+	}
+	return res, nil
+}
+
+func (r *Runtime) ContainerLogs(ctx context.Context, id string) ([]byte, error) {
+	if err := r.call("ContainerLogs", id); err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ctr, ok := r.containers[id]
+	if !ok {
+		return nil, noSuchContainer(id)
+	}
+	return append([]byte(nil), ctr.Logs...), nil
+}
+
+// ContainerStreamLogs writes the logs of a container to stdout. Only Tail is
+// honoured among the options.
+func (r *Runtime) ContainerStreamLogs(ctx context.Context, id string, opts *podman.LogOptions, stdout, stderr io.Writer) error {
+	if err := r.call("ContainerStreamLogs", id, opts); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	ctr, ok := r.containers[id]
+	var logs []byte
+	if ok {
+		logs = append(logs, ctr.Logs...)
+	}
+	r.mu.Unlock()
+	if !ok {
+		return noSuchContainer(id)
+	}
+	if opts != nil && opts.Tail > 0 {
+		lines := bytes.SplitAfter(logs, []byte("\n"))
+		if len(lines[len(lines)-1]) == 0 {
+			lines = lines[:len(lines)-1]
+		}
+		if len(lines) > opts.Tail {
+			lines = lines[len(lines)-opts.Tail:]
+		}
+		logs = bytes.Join(lines, nil)
+	}
+	_, err := stdout.Write(logs)
+	return err
+}
+
+func (r *Runtime) ContainerWaitForLog(ctx context.Context, id, substr string) error {
+	if err := r.call("ContainerWaitForLog", id, substr); err != nil {
+		return err
+	}
+	return r.waitUntil(ctx, func() (bool, error) {
+		ctr, ok := r.containers[id]
+		if !ok {
+			return false, noSuchContainer(id)
+		}
+		for _, line := range strings.Split(string(ctr.Logs), "\n") {
+			if strings.Contains(line, substr) {
+				return true, nil
+			}
+		}
+		if !ctr.Running {
+			return false, fmt.Errorf("container %s exited before logging %q", id, substr)
+		}
+		return false, nil
+	})
+}
+
+func (r *Runtime) VolumeCreate(ctx context.Context, opts *podman.VolumeOptions) (*entities.VolumeConfigResponse, error) {
+	if err := r.call("VolumeCreate", opts); err != nil {
+		return nil, err
+	}
+	if opts == nil {
+		opts = &podman.VolumeOptions{}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	name := opts.Name
+	if name == "" {
+		name = r.nextID("volume")
+	}
+	if _, ok := r.volumes[name]; ok {
+		return nil, fmt.Errorf("volume with name %s already exists: %w", name, podman.ErrConflict)
+	}
+	vol := &volume{data: define.InspectVolumeData{
+		Name:       name,
+		Driver:     "local",
+		Mountpoint: "/var/lib/containers/storage/volumes/" + name + "/_data",
+		Labels:     opts.Labels,
+		Options:    opts.Options,
+	}}
+	r.volumes[name] = vol
+	return &entities.VolumeConfigResponse{InspectVolumeData: vol.data}, nil
+}
+
+func (r *Runtime) VolumeInspect(ctx context.Context, name string) (*entities.VolumeConfigResponse, error) {
+	if err := r.call("VolumeInspect", name); err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	vol, ok := r.volumes[name]
+	if !ok {
+		return nil, noSuchVolume(name)
+	}
+	return &entities.VolumeConfigResponse{InspectVolumeData: vol.data}, nil
+}
+
+// VolumeExport writes the archive last imported into the volume, or an
+// empty archive.
+func (r *Runtime) VolumeExport(ctx context.Context, name string, w io.Writer) error {
+	if err := r.call("VolumeExport", name); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	vol, ok := r.volumes[name]
+	var archive []byte
+	if ok {
+		archive = vol.archive
+	}
+	r.mu.Unlock()
+	if !ok {
+		return noSuchVolume(name)
+	}
+	if archive == nil {
+		return tar.NewWriter(w).Close()
+	}
+	_, err := w.Write(archive)
+	return err
+}
+
+// VolumeImport stores the archive read from r as the contents of the
+// volume, replacing earlier imports. owner is only recorded.
+func (r *Runtime) VolumeImport(ctx context.Context, name string, rd io.Reader, owner *podman.Owner) error {
+	if err := r.call("VolumeImport", name, owner); err != nil {
+		return err
+	}
+	archive, err := io.ReadAll(rd)
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	vol, ok := r.volumes[name]
+	if !ok {
+		return noSuchVolume(name)
+	}
+	vol.archive = archive
+	return nil
+}
+
+func (r *Runtime) VolumeRemove(ctx context.Context, name string) error {
+	if err := r.call("VolumeRemove", name); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.volumes[name]; !ok {
+		return noSuchVolume(name)
+	}
+	delete(r.volumes, name)
+	return nil
+}
+
+// volume is the state the fake keeps for a volume.
+type volume struct {
+	data    define.InspectVolumeData
+	archive []byte
+}
+
+func noSuchVolume(name string) error {
+	return fmt.Errorf("no volume with name %q found: %w", name, podman.ErrNotFound)
+}
+
+func (r *Runtime) ImagePull(ctx context.Context, name string, policy podman.PullPolicy) (string, error) {
+	if err := r.call("ImagePull", name, policy); err != nil {
+		return "", err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if image, ok := r.images[name]; ok {
+		return image.ID, nil
+	}
+	if policy == podman.PullNever {
+		return "", noSuchImage(name)
+	}
+	image := &podman.ImageSummary{ID: r.nextID("image"), RepoTags: []string{name}}
+	r.images[name] = image
+	return image.ID, nil
+}
+
+func (r *Runtime) ImageTag(ctx context.Context, nameOrID, target string) error {
+	if err := r.call("ImageTag", nameOrID, target); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	image := r.lookupImage(nameOrID)
+	if image == nil {
+		return noSuchImage(nameOrID)
+	}
+	image.RepoTags = append(image.RepoTags, target)
+	r.images[target] = image
+	return nil
+}
+
+func (r *Runtime) ImageInspect(ctx context.Context, nameOrID string) (*podman.ImageSummary, error) {
+	if err := r.call("ImageInspect", nameOrID); err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	image := r.lookupImage(nameOrID)
+	if image == nil {
+		return nil, noSuchImage(nameOrID)
+	}
+	cp := *image
+	return &cp, nil
+}
+
+// ImageBuild registers an image under every tag in opts. Nothing is written
+// to opts.Output.
+func (r *Runtime) ImageBuild(ctx context.Context, opts *podman.BuildOptions) (string, error) {
+	if err := r.call("ImageBuild", opts); err != nil {
+		return "", err
+	}
+	if opts == nil || opts.ContextDir == "" {
+		return "", errors.New("building an image needs a context directory")
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	image := &podman.ImageSummary{ID: r.nextID("image"), RepoTags: opts.Tags, Labels: opts.Labels}
+	r.images[image.ID] = image
+	for _, tag := range opts.Tags {
+		r.images[tag] = image
+	}
+	return image.ID, nil
+}
+
+func (r *Runtime) ImagesRemove(ctx context.Context, names []string) (*entities.ImageRemoveReport, error) {
+	if err := r.call("ImagesRemove", names); err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	report := &entities.ImageRemoveReport{}
+	var errs []error
+	for _, name := range names {
+		image := r.lookupImage(name)
+		if image == nil {
+			errs = append(errs, noSuchImage(name))
+			continue
+		}
+		for key, other := range r.images {
+			if other == image {
+				delete(r.images, key)
+			}
+		}
+		report.Untagged = append(report.Untagged, image.RepoTags...)
+		report.Deleted = append(report.Deleted, image.ID)
+	}
+	if len(errs) > 0 {
+		report.ExitCode = 1
+	}
+	return report, errors.Join(errs...)
+}
+
+// lookupImage finds an image by name or ID. r.mu must be held.
+func (r *Runtime) lookupImage(nameOrID string) *podman.ImageSummary {
+	if image, ok := r.images[nameOrID]; ok {
+		return image
+	}
+	for _, image := range r.images {
+		if image.ID == nameOrID {
+			return image
+		}
+	}
+	return nil
+}
+
+func (r *Runtime) NetworkCreate(ctx context.Context, name string, opts *podman.NetworkOptions) (string, error) {
+	if err := r.call("NetworkCreate", name, opts); err != nil {
+		return "", err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.networks[name]; ok {
+		return "", fmt.Errorf("network name %s already used: %w", name, podman.ErrConflict)
+	}
+	id := r.nextID("network")
+	r.networks[name] = id
+	return id, nil
+}
+
+func (r *Runtime) NetworkRemove(ctx context.Context, name string) error {
+	if err := r.call("NetworkRemove", name); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.networks[name]; !ok {
+		return noSuchNetwork(name)
+	}
+	for id, ctr := range r.containers {
+		if _, ok := ctr.Networks[name]; ok {
+			return fmt.Errorf("network %s is being used by container %s: %w", name, id, podman.ErrConflict)
+		}
+	}
+	delete(r.networks, name)
+	return nil
+}
+
+func (r *Runtime) NetworkConnect(ctx context.Context, network, id string, aliases ...string) error {
+	if err := r.call("NetworkConnect", network, id, aliases); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.networks[network]; !ok {
+		return noSuchNetwork(network)
+	}
+	ctr, ok := r.containers[id]
+	if !ok {
+		return noSuchContainer(id)
+	}
+	r.next++
+	ctr.Networks[network] = fmt.Sprintf("10.89.0.%d", r.next+1)
+	return nil
+}
+
+func (r *Runtime) NetworkDisconnect(ctx context.Context, network, id string) error {
+	if err := r.call("NetworkDisconnect", network, id); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ctr, ok := r.containers[id]
+	if !ok {
+		return noSuchContainer(id)
+	}
+	if _, ok := ctr.Networks[network]; !ok {
+		return fmt.Errorf("container %s is not connected to network %s", id, network)
+	}
+	delete(ctr.Networks, network)
+	return nil
+}
+
+// subscriber is a ContainerEvents subscription.
+type subscriber struct {
+	ctx    context.Context
+	filter podman.EventFilter
+	// mu serializes sends with closing ch.
+	mu     sync.Mutex
+	closed bool
+	ch     chan podman.ContainerEvent
+}
+
+func (s *subscriber) matches(ev podman.ContainerEvent, labels map[string]string) bool {
+	if len(s.filter.ContainerIDs) > 0 && !slices.Contains(s.filter.ContainerIDs, ev.ContainerID) {
+		return false
+	}
+	if len(s.filter.Kinds) > 0 && !slices.Contains(s.filter.Kinds, ev.Kind) {
+		return false
+	}
+	for key, value := range s.filter.Labels {
+		if v, ok := labels[key]; !ok || v != value {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *subscriber) send(ev podman.ContainerEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+	select {
+	case <-s.ctx.Done():
+	case s.ch <- ev:
+	}
+}
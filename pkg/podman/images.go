@@ -0,0 +1,225 @@
+package podman
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	buildahDefine "github.com/containers/buildah/define"
+	bimages "github.com/containers/podman/v5/pkg/bindings/images"
+	"github.com/containers/podman/v5/pkg/domain/entities"
+)
+
+// PullPolicy decides whether an image is pulled from its registry.
+type PullPolicy string
+
+const (
+	// PullMissing pulls only when the image is not present locally.
+	PullMissing PullPolicy = "missing"
+	// PullAlways pulls even when the image is present locally.
+	PullAlways PullPolicy = "always"
+	// PullNewer pulls when the registry has a newer image.
+	PullNewer PullPolicy = "newer"
+	// PullNever never pulls and fails when the image is missing.
+	PullNever PullPolicy = "never"
+)
+
+func (p PullPolicy) buildah() buildahDefine.PullPolicy {
+	switch p {
+	case PullAlways:
+		return buildahDefine.PullAlways
+	case PullNewer:
+		return buildahDefine.PullIfNewer
+	case PullNever:
+		return buildahDefine.PullNever
+	default:
+		return buildahDefine.PullIfMissing
+	}
+}
+
+// BuildOptions describes an image build by ImageBuild.
+type BuildOptions struct {
+	// ContextDir is the build context sent to the service.
+	ContextDir string
+	// Containerfile is the path of the Containerfile, relative to
+	// ContextDir unless absolute. It defaults to ContextDir/Dockerfile.
+	Containerfile string
+	// Tags name the resulting image.
+	Tags []string
+	// Args are passed as --build-arg values.
+	Args map[string]string
+	// Target selects the stage of a multi-stage build.
+	Target string
+	// Labels are added to the resulting image.
+	Labels map[string]string
+	// Layers caches intermediate layers, NoCache ignores existing ones.
+	Layers  bool
+	NoCache bool
+	// Pull applies to base images and defaults to PullMissing.
+	Pull PullPolicy
+	// Output receives the build output. It is discarded when nil.
+	Output io.Writer
+}
+
+// ImageBuild builds an image and returns its ID. By default base images are
+// only pulled when missing, so locally built candidate images can be used in
+// FROM. opts.ContextDir is required.
+func (c *Client) ImageBuild(ctx context.Context, opts *BuildOptions) (string, error) {
+	if opts == nil || opts.ContextDir == "" {
+		return "", errors.New("building an image needs a context directory")
+	}
+	containerfile := opts.Containerfile
+	if containerfile == "" {
+		containerfile = "Dockerfile"
+	}
+	if !filepath.IsAbs(containerfile) {
+		containerfile = filepath.Join(opts.ContextDir, containerfile)
+	}
+	out := opts.Output
+	if out == nil {
+		out = io.Discard
+	}
+
+	buildOptions := entities.BuildOptions{
+		BuildOptions: buildahDefine.BuildOptions{
+			ContextDirectory: opts.ContextDir,
+			Args:             opts.Args,
+			Target:           opts.Target,
+			Layers:           opts.Layers,
+			NoCache:          opts.NoCache,
+			PullPolicy:       opts.Pull.buildah(),
+			Out:              out,
+			Err:              out,
+			ReportWriter:     out,
+		},
+	}
+	if len(opts.Tags) > 0 {
+		buildOptions.Output = opts.Tags[0]
+		buildOptions.AdditionalTags = opts.Tags[1:]
+	}
+	for k, v := range c.addLabels(opts.Labels) {
+		buildOptions.Labels = append(buildOptions.Labels, k+"="+v)
+	}
+
+	report, err := bimages.Build(c.conn(ctx), []string{containerfile}, buildOptions)
+	if err != nil {
+		return "", classify(err)
+	}
+	if report.ID == "" {
+		return "", fmt.Errorf("build of %s did not report an image ID", opts.ContextDir)
+	}
+	c.Tracker.Add(ResourceImage, report.ID)
+	return report.ID, nil
+}
+
+// ImagePull makes name available locally according to policy, which defaults
+// to PullMissing, and returns the ID of the local image.
+func (c *Client) ImagePull(ctx context.Context, name string, policy PullPolicy) (string, error) {
+	if policy == "" {
+		policy = PullMissing
+	}
+	pullOptions := new(bimages.PullOptions).WithPolicy(string(policy)).WithQuiet(true)
+	ids, err := bimages.Pull(c.conn(ctx), name, pullOptions)
+	if err != nil {
+		return "", classify(err)
+	}
+	if len(ids) == 0 {
+		return "", fmt.Errorf("pull of %s did not report an image ID", name)
+	}
+	return ids[0], nil
+}
+
+// ImageTag adds the name target, in repo[:tag] form, to the local image
+// nameOrID. The tag defaults to latest.
+func (c *Client) ImageTag(ctx context.Context, nameOrID, target string) error {
+	repo, tag := target, "latest"
+	if i := strings.LastIndex(target, ":"); i > strings.LastIndex(target, "/") {
+		repo, tag = target[:i], target[i+1:]
+	}
+	return classify(bimages.Tag(c.conn(ctx), nameOrID, tag, repo, &bimages.TagOptions{}))
+}
+
+// ImageSummary is the part of an image's configuration that the tests make
+// assertions about.
+type ImageSummary struct {
+	ID         string
+	RepoTags   []string
+	Created    time.Time
+	Labels     map[string]string
+	Env        []string
+	Entrypoint []string
+	Cmd        []string
+	User       string
+	WorkingDir string
+	// ExposedPorts and Volumes are sorted, ports in "8080/tcp" form.
+	ExposedPorts []string
+	Volumes      []string
+}
+
+// ImageInspect returns a summary of a local image.
+func (c *Client) ImageInspect(ctx context.Context, nameOrID string) (*ImageSummary, error) {
+	data, err := bimages.GetImage(c.conn(ctx), nameOrID, &bimages.GetOptions{})
+	if err != nil {
+		return nil, classify(err)
+	}
+	summary := &ImageSummary{
+		ID:       data.ID,
+		RepoTags: data.RepoTags,
+		Labels:   data.Labels,
+		User:     data.User,
+	}
+	if data.Created != nil {
+		summary.Created = *data.Created
+	}
+	if cfg := data.Config; cfg != nil {
+		summary.Env = cfg.Env
+		summary.Entrypoint = cfg.Entrypoint
+		summary.Cmd = cfg.Cmd
+		summary.WorkingDir = cfg.WorkingDir
+		if summary.User == "" {
+			summary.User = cfg.User
+		}
+		for port := range cfg.ExposedPorts {
+			summary.ExposedPorts = append(summary.ExposedPorts, port)
+		}
+		for volume := range cfg.Volumes {
+			summary.Volumes = append(summary.Volumes, volume)
+		}
+		if summary.Labels == nil {
+			summary.Labels = cfg.Labels
+		}
+	}
+	sort.Strings(summary.ExposedPorts)
+	sort.Strings(summary.Volumes)
+	return summary, nil
+}
+
+// VerifyLabels checks that the image carries every label in want. An empty
+// value only requires the label to be present and non-empty. All mismatches
+// are reported in the returned error.
+func (s *ImageSummary) VerifyLabels(want map[string]string) error {
+	keys := make([]string, 0, len(want))
+	for k := range want {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var errs []error
+	for _, k := range keys {
+		got, ok := s.Labels[k]
+		switch {
+		case !ok:
+			errs = append(errs, fmt.Errorf("label %s is missing", k))
+		case want[k] == "" && got == "":
+			errs = append(errs, fmt.Errorf("label %s is empty", k))
+		case want[k] != "" && got != want[k]:
+			errs = append(errs, fmt.Errorf("label %s is %q, want %q", k, got, want[k]))
+		}
+	}
+	return errors.Join(errs...)
+}
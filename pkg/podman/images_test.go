@@ -0,0 +1,19 @@
+package podman
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Images", func() {
+	It("should refuse to build without a context directory", func() {
+		c := &Client{}
+		_, err := c.ImageBuild(context.Background(), nil)
+		Expect(err).To(MatchError(ContainSubstring("context directory")))
+
+		_, err = c.ImageBuild(context.Background(), &BuildOptions{Tags: []string{"image"}})
+		Expect(err).To(MatchError(ContainSubstring("context directory")))
+	})
+})
@@ -0,0 +1,153 @@
+package podman
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strconv"
+	"time"
+
+	bcontainers "github.com/containers/podman/v5/pkg/bindings/containers"
+)
+
+// LogOptions selects the part of a container's log returned by
+// ContainerStreamLogs. The zero value returns the whole log without following.
+type LogOptions struct {
+	// Follow keeps streaming until the container exits or the context is
+	// cancelled.
+	Follow bool
+	// Since and Until bound the log by timestamp when non-zero.
+	Since time.Time
+	Until time.Time
+	// Tail limits the output to the last Tail lines when positive.
+	Tail int
+	// Timestamps prefixes every line with its timestamp.
+	Timestamps bool
+}
+
+// ContainerLogs returns the interleaved stdout and stderr of a container.
+func (c *Client) ContainerLogs(ctx context.Context, id string) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := c.ContainerStreamLogs(ctx, id, nil, &buf, &buf); err != nil {
+		return nil, classify(err)
+	}
+	return buf.Bytes(), nil
+}
+
+// ContainerStreamLogs copies a container's stdout and stderr to the given
+// writers as the service produces them. Either writer may be nil to drop that
+// stream. It returns when the log ends, when a write fails, or when ctx is
+// done.
+func (c *Client) ContainerStreamLogs(ctx context.Context, id string, opts *LogOptions, stdout, stderr io.Writer) error {
+	if opts == nil {
+		opts = &LogOptions{}
+	}
+	if stdout == nil {
+		stdout = io.Discard
+	}
+	if stderr == nil {
+		stderr = io.Discard
+	}
+
+	logOptions := new(bcontainers.LogOptions).
+		WithStdout(true).
+		WithStderr(true).
+		WithFollow(opts.Follow).
+		WithTimestamps(opts.Timestamps)
+	if !opts.Since.IsZero() {
+		logOptions.WithSince(opts.Since.Format(time.RFC3339Nano))
+	}
+	if !opts.Until.IsZero() {
+		logOptions.WithUntil(opts.Until.Format(time.RFC3339Nano))
+	}
+	if opts.Tail > 0 {
+		logOptions.WithTail(strconv.Itoa(opts.Tail))
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stdOutChan := make(chan string)
+	stdErrChan := make(chan string)
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- bcontainers.Logs(c.conn(ctx), id, logOptions, stdOutChan, stdErrChan)
+	}()
+
+	// Keep receiving after a failed write so that the bindings never block
+	// on a send; cancelling ctx makes them return shortly afterwards.
+	var writeErr error
+	write := func(w io.Writer, frame string) {
+		if writeErr != nil {
+			return
+		}
+		if _, err := io.WriteString(w, frame); err != nil {
+			writeErr = err
+			cancel()
+		}
+	}
+	for {
+		select {
+		case frame := <-stdOutChan:
+			write(stdout, frame)
+		case frame := <-stdErrChan:
+			write(stderr, frame)
+		case err := <-errCh:
+			if writeErr != nil {
+				return writeErr
+			}
+			return classify(err)
+		}
+	}
+}
+
+// ContainerWaitForLog follows a container's log until a line containing
+// substr appears, returning an error if the log ends first or ctx is done.
+func (c *Client) ContainerWaitForLog(ctx context.Context, id, substr string) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	m := &logMatcher{substr: []byte(substr), matched: cancel}
+	err := c.ContainerStreamLogs(ctx, id, &LogOptions{Follow: true}, m, m)
+	if m.found {
+		return nil
+	}
+	if err == nil {
+		err = errors.New("log ended before " + strconv.Quote(substr) + " appeared")
+	}
+	return classify(err)
+}
+
+// logMatcher scans the log line by line for substr and calls matched once it
+// has been seen.
+type logMatcher struct {
+	substr  []byte
+	line    []byte
+	found   bool
+	matched func()
+}
+
+func (m *logMatcher) Write(p []byte) (int, error) {
+	if m.found {
+		return len(p), nil
+	}
+	m.line = append(m.line, p...)
+	for {
+		i := bytes.IndexByte(m.line, '\n')
+		if i < 0 {
+			break
+		}
+		if bytes.Contains(m.line[:i], m.substr) {
+			m.found = true
+			m.matched()
+			return len(p), nil
+		}
+		m.line = m.line[i+1:]
+	}
+	if bytes.Contains(m.line, m.substr) {
+		m.found = true
+		m.matched()
+	}
+	return len(p), nil
+}
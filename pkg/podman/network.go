@@ -0,0 +1,109 @@
+package podman
+
+import (
+	"context"
+	"fmt"
+
+	nettypes "github.com/containers/common/libnetwork/types"
+	bcontainers "github.com/containers/podman/v5/pkg/bindings/containers"
+	bnetwork "github.com/containers/podman/v5/pkg/bindings/network"
+	"github.com/containers/podman/v5/pkg/specgen"
+)
+
+// NetworkOptions configures a network created by NetworkCreate.
+type NetworkOptions struct {
+	// Labels are applied to the network.
+	Labels map[string]string
+	// Internal networks have no route to the outside world.
+	Internal bool
+}
+
+// NetworkCreate creates a bridge network with name resolution enabled, so
+// containers attached to it can reach each other by name and alias. It
+// returns the network ID.
+func (c *Client) NetworkCreate(ctx context.Context, name string, opts *NetworkOptions) (string, error) {
+	if opts == nil {
+		opts = &NetworkOptions{}
+	}
+	network, err := bnetwork.Create(c.conn(ctx), &nettypes.Network{
+		Name:       name,
+		Driver:     "bridge",
+		DNSEnabled: true,
+		Internal:   opts.Internal,
+		Labels:     c.addLabels(opts.Labels),
+	})
+	if err != nil {
+		return "", classify(err)
+	}
+	c.Tracker.Add(ResourceNetwork, name)
+	return network.ID, nil
+}
+
+// NetworkRemove removes a network. It fails with ErrConflict while
+// containers are still attached to it.
+func (c *Client) NetworkRemove(ctx context.Context, name string) error {
+	reports, err := bnetwork.Remove(c.conn(ctx), name, &bnetwork.RemoveOptions{})
+	if err != nil {
+		return classify(err)
+	}
+	for _, r := range reports {
+		if r.Err != nil {
+			return classify(r.Err)
+		}
+	}
+	c.Tracker.Forget(ResourceNetwork, name)
+	return nil
+}
+
+// NetworkConnect attaches a container to a network under the given DNS
+// aliases, in addition to its name.
+func (c *Client) NetworkConnect(ctx context.Context, network, id string, aliases ...string) error {
+	return classify(bnetwork.Connect(c.conn(ctx), network, id, &nettypes.PerNetworkOptions{Aliases: aliases}))
+}
+
+// NetworkDisconnect detaches a container from a network.
+func (c *Client) NetworkDisconnect(ctx context.Context, network, id string) error {
+	return classify(bnetwork.Disconnect(c.conn(ctx), network, id, &bnetwork.DisconnectOptions{}))
+}
+
+// ContainerNetworkIPs returns the IPv4 address of a running container on each
+// network it is attached to, keyed by network name.
+func (c *Client) ContainerNetworkIPs(ctx context.Context, id string) (map[string]string, error) {
+	data, err := bcontainers.Inspect(c.conn(ctx), id, &bcontainers.InspectOptions{})
+	if err != nil {
+		return nil, classify(err)
+	}
+	ips := map[string]string{}
+	if data.NetworkSettings == nil {
+		return ips, nil
+	}
+	for name, network := range data.NetworkSettings.Networks {
+		if network != nil && network.IPAddress != "" {
+			ips[name] = network.IPAddress
+		}
+	}
+	return ips, nil
+}
+
+// ContainerNetworkIP returns the IPv4 address of a running container on one
+// network.
+func (c *Client) ContainerNetworkIP(ctx context.Context, id, network string) (string, error) {
+	ips, err := c.ContainerNetworkIPs(ctx, id)
+	if err != nil {
+		return "", classify(err)
+	}
+	ip, ok := ips[network]
+	if !ok {
+		return "", fmt.Errorf("container %s has no address on network %s", id, network)
+	}
+	return ip, nil
+}
+
+// JoinNetwork makes a container created from spec join network under the
+// given DNS aliases.
+func JoinNetwork(spec *specgen.SpecGenerator, network string, aliases ...string) {
+	if spec.Networks == nil {
+		spec.Networks = map[string]nettypes.PerNetworkOptions{}
+	}
+	spec.Networks[network] = nettypes.PerNetworkOptions{Aliases: aliases}
+}
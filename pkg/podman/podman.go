@@ -3,17 +3,11 @@ package podman
 // https://github.com/containers/podman/tree/main/pkg/bindings
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
-	"fmt"
-	"io"
+	"errors"
 	"os"
-	"strings"
 	"time"
 
-	"github.com/containers/podman/v5/libpod/define"
-	"github.com/containers/podman/v5/pkg/api/handlers"
 	"github.com/containers/podman/v5/pkg/bindings"
 	bcontainers "github.com/containers/podman/v5/pkg/bindings/containers"
 	bimages "github.com/containers/podman/v5/pkg/bindings/images"
@@ -23,269 +17,172 @@ import (
 	"github.com/containers/podman/v5/pkg/specgen"
 )
 
+// Client talks to the podman service. Client holds the connection context
+// returned by bindings.NewConnection; it is only used as the base from which
+// per-call contexts are derived, so every method takes its own context for
+// cancellation and deadlines.
 type Client struct {
 	Client *context.Context
+	// Labels are added to every container, volume, network and image the
+	// client creates.
+	Labels map[string]string
+	// Tracker, when set, records every resource the client creates.
+	Tracker *Tracker
+	// HelperImage is used for the helper containers that access volumes.
+	// It defaults to DefaultHelperImage.
+	HelperImage string
 }
 
-func NewEnvClient() (*Client, error) {
-	client, err := bindings.NewConnection(context.Background(), "unix://run/user/1000/podman/podman.sock")
-	if err != nil {
-		return nil, err
-	}
-	return &Client{Client: &client}, err
+// connContext carries the cancellation and deadline of the caller's context
+// and falls back to the connection context for values, which is where the
+// bindings look up the podman connection.
+type connContext struct {
+	context.Context
+	base context.Context
 }
 
-func (c *Client) ExecInActiveContainers(w io.Writer, ctx context.Context, cmd []string) {
-	for {
-		time.Sleep(60 * time.Second)
-		select {
-		case <-ctx.Done():
-			return
-		default:
-			containers, err := bcontainers.List(*c.Client, &bcontainers.ListOptions{})
-			if err != nil {
-				fmt.Fprintf(w, "container list error: %#v\n", err)
-				continue
-			}
-			fmt.Fprintf(w, "found %d containers\n", len(containers))
-			for _, container := range containers {
-				fmt.Fprintf(w, "found container %s running command %s\n", container.ID, container.Command)
-				createConfig := new(handlers.ExecCreateConfig)
-				createConfig.Cmd = cmd
-				id, err := bcontainers.ExecCreate(*c.Client, container.ID, createConfig)
-				if err != nil {
-					fmt.Fprintf(w, "container ExecCreate error: %#v\n", err)
-					continue
-				}
-				if err := bcontainers.ExecStart(*c.Client, id, &bcontainers.ExecStartOptions{}); err != nil {
-					fmt.Fprintf(w, "container ExecStart error: %#v\n", err)
-					continue
-				}
-				rPipe, wPipe, err := os.Pipe()
-				if err != nil {
-					fmt.Fprintf(w, "error creating pipes: %#v\n", err)
-					continue
-				}
-				defer wPipe.Close()
-				defer rPipe.Close()
-
-				rErrPipe, wErrPipe, err := os.Pipe()
-				if err != nil {
-					fmt.Fprintf(w, "error creating pipes: %#v\n", err)
-					continue
-				}
-				defer wErrPipe.Close()
-				defer rErrPipe.Close()
-
-				streams := define.AttachStreams{
-					OutputStream: wPipe,
-					ErrorStream:  wErrPipe,
-					InputStream:  nil,
-					AttachOutput: true,
-					AttachError:  true,
-					AttachInput:  false,
-				}
-				startAndAttachOptions := new(bcontainers.ExecStartAndAttachOptions)
-				startAndAttachOptions.WithOutputStream(streams.OutputStream).WithErrorStream(streams.ErrorStream)
-				if streams.InputStream != nil {
-					startAndAttachOptions.WithInputStream(*streams.InputStream)
-				}
-				startAndAttachOptions.WithAttachError(streams.AttachError).WithAttachOutput(streams.AttachOutput).WithAttachInput(streams.AttachInput)
-				if err := bcontainers.ExecStartAndAttach(*c.Client, id, startAndAttachOptions); err != nil {
-					fmt.Fprintf(w, "container exec error: %#v\n", err)
-					continue
-				}
-
-				bytes, err := io.ReadAll(rPipe)
-				if err != nil {
-					fmt.Fprintf(w, "error creating pipes: %#v\n", err)
-					continue
-				}
-
-				fmt.Fprintf(w, "exec of command %#v into %s had text %s\n", cmd, container.ID, string(bytes))
-			}
-		}
+func (c connContext) Value(key any) any {
+	if v := c.Context.Value(key); v != nil {
+		return v
 	}
+	return c.base.Value(key)
 }
 
-func (c *Client) InspectActiveContainers(w io.Writer, ctx context.Context) {
-	for {
-		time.Sleep(60 * time.Second)
-		select {
-		case <-ctx.Done():
-			return
-		default:
-			containers, err := bcontainers.List(*c.Client, &bcontainers.ListOptions{})
-			if err != nil {
-				fmt.Fprintf(w, "container list error: %#v\n", err)
-				continue
-			}
-			fmt.Fprintf(w, "found %d containers\n", len(containers))
-			for _, container := range containers {
-				fmt.Fprintf(w, "found container %s running command %s\n", container.ID, container.Command)
-				data, err := bcontainers.Inspect(*c.Client, container.ID, &bcontainers.InspectOptions{})
-				if err != nil {
-					fmt.Fprintf(w, "container inspect error: %#v\n", err)
-					continue
-				}
-				body, err := json.Marshal(data)
-				if err != nil {
-					fmt.Fprintf(w, "inspect of %s returned raw json %s\n", container.ID, string(body))
-					continue
-				}
-				var prettyJSON bytes.Buffer
-				error := json.Indent(&prettyJSON, body, "", "\t")
-				if error != nil {
-					fmt.Fprintf(w, "inspect of %s returned raw json %s\n", container.ID, string(body))
-					continue
-				}
-				fmt.Fprintf(w, "inspect of %s returned formatted json:\n%s\n", container.ID, prettyJSON.String())
-			}
-		}
-	}
+// conn returns a context usable with the bindings that is bounded by ctx.
+func (c *Client) conn(ctx context.Context) context.Context {
+	return connContext{Context: ctx, base: *c.Client}
 }
 
-func (c *Client) ContainerList() ([]entities.ListContainer, error) {
-	return bcontainers.List(*c.Client, &bcontainers.ListOptions{})
-}
-
-func (c *Client) ContainerCreate(config *specgen.SpecGenerator) (string, error) {
-	resp, err := bcontainers.CreateWithSpec(*c.Client, config, &bcontainers.CreateOptions{})
-	return resp.ID, err
-}
-
-func (c *Client) ContainerExec(id string, cmd []string) (int, []byte, error) {
-	createConfig := new(handlers.ExecCreateConfig)
-	createConfig.Cmd = cmd
-	createConfig.AttachStdout = true
-	id, err := bcontainers.ExecCreate(*c.Client, id, createConfig)
-
-	if err != nil {
-		return 0, nil, err
-	}
-	rPipe, wPipe, err := os.Pipe()
-	if err != nil {
-		return 0, nil, err
+// runCtx runs fn in the background and returns ctx.Err() as soon as ctx is
+// done. Some bindings read from hijacked connections that ignore
+// cancellation; fn keeps running until the service closes the stream.
+func runCtx(ctx context.Context, fn func() error) error {
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- fn()
+	}()
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
 	}
-	defer wPipe.Close()
-	defer rPipe.Close()
+}
 
-	rErrPipe, wErrPipe, err := os.Pipe()
+// NewEnvClient connects to the podman service of the current user. The
+// client labels its resources with a new run ID, or with TEST_RUN_ID when
+// set, and tracks them.
+func NewEnvClient() (*Client, error) {
+	client, err := bindings.NewConnection(context.Background(), "unix://run/user/1000/podman/podman.sock")
 	if err != nil {
-		return 0, nil, err
-	}
-	defer wErrPipe.Close()
-	defer rErrPipe.Close()
-
-	streams := define.AttachStreams{
-		OutputStream: wPipe,
-		ErrorStream:  wErrPipe,
-		InputStream:  nil,
-		AttachOutput: true,
-		AttachError:  true,
-		AttachInput:  false,
-	}
-	startAndAttachOptions := new(bcontainers.ExecStartAndAttachOptions)
-	startAndAttachOptions.WithOutputStream(streams.OutputStream).WithErrorStream(streams.ErrorStream)
-	if streams.InputStream != nil {
-		startAndAttachOptions.WithInputStream(*streams.InputStream)
+		return nil, err
 	}
-	startAndAttachOptions.WithAttachError(streams.AttachError).WithAttachOutput(streams.AttachOutput).WithAttachInput(streams.AttachInput)
-	if err := bcontainers.ExecStartAndAttach(*c.Client, id, startAndAttachOptions); err != nil {
-		return 0, nil, err
+	started := time.Now()
+	runID := os.Getenv("TEST_RUN_ID")
+	if runID == "" {
+		runID = NewRunID(started)
 	}
+	return &Client{
+		Client:  &client,
+		Labels:  RunLabels(runID, started),
+		Tracker: NewTracker(),
+	}, err
+}
 
-	bytes, err := io.ReadAll(rPipe)
-	if err != nil {
-		return 0, nil, err
-	}
+func (c *Client) ContainerList(ctx context.Context) ([]entities.ListContainer, error) {
+	containers, err := bcontainers.List(c.conn(ctx), &bcontainers.ListOptions{})
+	return containers, classify(err)
+}
 
-	inspect, err := bcontainers.ExecInspect(*c.Client, id, &bcontainers.ExecInspectOptions{})
+func (c *Client) ContainerCreate(ctx context.Context, config *specgen.SpecGenerator) (string, error) {
+	config.Labels = c.addLabels(config.Labels)
+	resp, err := bcontainers.CreateWithSpec(c.conn(ctx), config, &bcontainers.CreateOptions{})
 	if err != nil {
-		return 0, nil, err
+		return "", classify(err)
 	}
-
-	return inspect.ExitCode, bytes, nil
+	c.Tracker.Add(ResourceContainer, resp.ID)
+	return resp.ID, nil
 }
 
-func (c *Client) ContainerInspect(id string) (string, error) {
-	data, err := bcontainers.Inspect(*c.Client, id, &bcontainers.InspectOptions{})
+func (c *Client) ContainerInspect(ctx context.Context, id string) (string, error) {
+	data, err := bcontainers.Inspect(c.conn(ctx), id, &bcontainers.InspectOptions{})
 	if err != nil {
-		return "", err
+		return "", classify(err)
 	}
 	return data.NetworkSettings.IPAddress, nil
 }
 
-func (c *Client) ContainerStart(id string) error {
-	return bcontainers.Start(*c.Client, id, &bcontainers.StartOptions{})
+func (c *Client) ContainerStart(ctx context.Context, id string) error {
+	return classify(bcontainers.Start(c.conn(ctx), id, &bcontainers.StartOptions{}))
 }
 
-func (c *Client) ContainerLogs(id string) ([]byte, error) {
-	fmt.Printf("Container ID: %s \n", id)
-	data, err := bcontainers.Inspect(*c.Client, id, &bcontainers.InspectOptions{})
+// ContainerRemove removes a stopped container. Removing a container that
+// does not exist fails with ErrNotFound, see IgnoreNotFound.
+func (c *Client) ContainerRemove(ctx context.Context, id string) ([]*reports.RmReport, error) {
+	reports, err := bcontainers.Remove(c.conn(ctx), id, &bcontainers.RemoveOptions{})
 	if err != nil {
-		return []byte{}, err
-	}
-	fmt.Printf("DATA: %s", data.State.Status)
-	truePtr := true
-	stdOutChan := make(chan string, 100)
-	stdErrChan := make(chan string, 100)
-
-	if err := bcontainers.Logs(*c.Client, id, &bcontainers.LogOptions{
-		Stdout: &truePtr,
-		Stderr: &truePtr,
-	}, stdOutChan, stdErrChan); err != nil {
-		return nil, err
-	}
-
-	close(stdOutChan)
-	close(stdErrChan)
-
-	allLogs := []string{}
-	for e := range stdOutChan {
-		allLogs = append(allLogs, e)
-		fmt.Printf("stdOutChan: %s", e)
+		return nil, classify(err)
 	}
-	for e := range stdErrChan {
-		allLogs = append(allLogs, e)
-		fmt.Printf("stdErrChan: %s", e)
+	var errs []error
+	for _, r := range reports {
+		if r.Err != nil {
+			errs = append(errs, classify(r.Err))
+			continue
+		}
+		c.Tracker.Forget(ResourceContainer, r.Id)
 	}
-
-	return []byte(strings.Join(allLogs, "\n")), nil
-}
-
-func (c *Client) ContainerRemove(id string) ([]*reports.RmReport, error) {
-	return bcontainers.Remove(*c.Client, id, &bcontainers.RemoveOptions{})
+	return reports, errors.Join(errs...)
 }
 
-func (c *Client) ContainerStop(id string, timeout int) error {
-	stopOptions := new(bcontainers.StopOptions)
-	stopOptions.WithTimeout(uint(30))
-	return bcontainers.Stop(*c.Client, id, stopOptions)
+// ContainerStop stops a container, killing it if it is still running after
+// timeout seconds. Stopping a stopped container succeeds.
+func (c *Client) ContainerStop(ctx context.Context, id string, timeout int) error {
+	stopOptions := new(bcontainers.StopOptions).WithTimeout(uint(timeout))
+	return classify(bcontainers.Stop(c.conn(ctx), id, stopOptions))
 }
 
-func (c *Client) ContainerStopAndRemove(id string, timeout int) ([]*reports.RmReport, error) {
-	err := c.ContainerStop(id, timeout)
+func (c *Client) ContainerStopAndRemove(ctx context.Context, id string, timeout int) ([]*reports.RmReport, error) {
+	err := c.ContainerStop(ctx, id, timeout)
 	if err != nil {
 		return nil, err
 	}
-	return c.ContainerRemove(id)
-}
-
-func (c *Client) ContainerWait(id string) (int32, error) {
-	return bcontainers.Wait(*c.Client, id, &bcontainers.WaitOptions{})
+	return c.ContainerRemove(ctx, id)
 }
 
-func (c *Client) ImagesRemove(names []string) (*entities.ImageRemoveReport, []error) {
-	return bimages.Remove(*c.Client, names, &bimages.RemoveOptions{})
+func (c *Client) ContainerWait(ctx context.Context, id string) (int32, error) {
+	code, err := bcontainers.Wait(c.conn(ctx), id, &bcontainers.WaitOptions{})
+	return code, classify(err)
 }
 
-func (c *Client) VolumeCreate() (*entities.VolumeConfigResponse, error) {
-	return bvolumes.Create(*c.Client, entities.VolumeCreateOptions{}, &bvolumes.CreateOptions{})
+// ImagesRemove removes images by name or ID. The failures for all images
+// are joined into the returned error.
+func (c *Client) ImagesRemove(ctx context.Context, names []string) (*entities.ImageRemoveReport, error) {
+	report, rmErrs := bimages.Remove(c.conn(ctx), names, &bimages.RemoveOptions{})
+	if len(rmErrs) == 0 {
+		for _, name := range names {
+			c.Tracker.Forget(ResourceImage, name)
+		}
+	}
+	if report != nil {
+		for _, id := range report.Deleted {
+			c.Tracker.Forget(ResourceImage, id)
+		}
+	}
+	var errs []error
+	for _, err := range rmErrs {
+		errs = append(errs, classify(err))
+	}
+	return report, errors.Join(errs...)
 }
 
-func (c *Client) VolumeRemove(name string) error {
-	return bvolumes.Remove(*c.Client, name, &bvolumes.RemoveOptions{})
+// VolumeRemove removes a volume that is not in use. Removing a volume that
+// does not exist fails with ErrNotFound, see IgnoreNotFound.
+func (c *Client) VolumeRemove(ctx context.Context, name string) error {
+	if err := bvolumes.Remove(c.conn(ctx), name, &bvolumes.RemoveOptions{}); err != nil {
+		return classify(err)
+	}
+	c.Tracker.Forget(ResourceVolume, name)
+	return nil
 }
 
 func Duration(d time.Duration) *time.Duration {
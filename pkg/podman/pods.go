@@ -0,0 +1,107 @@
+package podman
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	nettypes "github.com/containers/common/libnetwork/types"
+	bpods "github.com/containers/podman/v5/pkg/bindings/pods"
+	"github.com/containers/podman/v5/pkg/domain/entities"
+	"github.com/containers/podman/v5/pkg/specgen"
+)
+
+// PodOptions configures a pod created by PodCreate.
+type PodOptions struct {
+	// Labels are applied to the pod.
+	Labels map[string]string
+	// Ports are TCP ports of the shared network namespace published on
+	// random free host ports, see PodHostPort.
+	Ports []uint16
+}
+
+// PodCreate creates a pod whose containers share the network, IPC and UTS
+// namespaces of its infra container, like the containers of a Kubernetes
+// pod. It returns the pod ID.
+func (c *Client) PodCreate(ctx context.Context, name string, opts *PodOptions) (string, error) {
+	if opts == nil {
+		opts = &PodOptions{}
+	}
+	podSpec := specgen.NewPodSpecGenerator()
+	podSpec.Name = name
+	podSpec.Labels = c.addLabels(opts.Labels)
+	podSpec.SharedNamespaces = []string{"ipc", "net", "uts"}
+	for _, port := range opts.Ports {
+		podSpec.PortMappings = append(podSpec.PortMappings, nettypes.PortMapping{
+			ContainerPort: port,
+			Protocol:      "tcp",
+		})
+	}
+	report, err := bpods.CreatePodFromSpec(c.conn(ctx), &entities.PodSpec{PodSpecGen: *podSpec})
+	if err != nil {
+		return "", classify(err)
+	}
+	c.Tracker.Add(ResourcePod, report.Id)
+	return report.Id, nil
+}
+
+// PodStart starts every container of a pod.
+func (c *Client) PodStart(ctx context.Context, nameOrID string) error {
+	report, err := bpods.Start(c.conn(ctx), nameOrID, &bpods.StartOptions{})
+	if err != nil {
+		return classify(err)
+	}
+	return errors.Join(report.Errs...)
+}
+
+// PodStop stops every container of a pod, killing those still running after
+// timeout seconds.
+func (c *Client) PodStop(ctx context.Context, nameOrID string, timeout int) error {
+	report, err := bpods.Stop(c.conn(ctx), nameOrID, new(bpods.StopOptions).WithTimeout(timeout))
+	if err != nil {
+		return classify(err)
+	}
+	return errors.Join(report.Errs...)
+}
+
+// PodRemove removes a pod along with its containers, stopping them first.
+func (c *Client) PodRemove(ctx context.Context, nameOrID string) error {
+	report, err := bpods.Remove(c.conn(ctx), nameOrID, new(bpods.RemoveOptions).WithForce(true))
+	if err != nil {
+		return classify(err)
+	}
+	errs := []error{report.Err}
+	for id, err := range report.RemovedCtrs {
+		if err != nil {
+			errs = append(errs, fmt.Errorf("removing container %s: %w", id, classify(err)))
+			continue
+		}
+		c.Tracker.Forget(ResourceContainer, id)
+	}
+	if err := errors.Join(errs...); err != nil {
+		return classify(err)
+	}
+	c.Tracker.Forget(ResourcePod, report.Id)
+	c.Tracker.Forget(ResourcePod, nameOrID)
+	return nil
+}
+
+// PodHostPort returns the host:port address that a port published with
+// PodOptions.Ports is reachable on from the host. The pod must be running.
+func (c *Client) PodHostPort(ctx context.Context, nameOrID string, port uint16) (string, error) {
+	report, err := bpods.Inspect(c.conn(ctx), nameOrID, &bpods.InspectOptions{})
+	if err != nil {
+		return "", classify(err)
+	}
+	if report.InfraContainerID == "" {
+		return "", fmt.Errorf("pod %s has no infra container", nameOrID)
+	}
+	return c.ContainerHostPort(ctx, report.InfraContainerID, port)
+}
+
+// JoinPod makes a container created from spec a member of pod, sharing the
+// pod's namespaces. Ports must be published on the pod instead of the
+// container.
+func JoinPod(spec *specgen.SpecGenerator, pod string) {
+	spec.Pod = pod
+}
@@ -0,0 +1,74 @@
+package podman
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	nettypes "github.com/containers/common/libnetwork/types"
+	bcontainers "github.com/containers/podman/v5/pkg/bindings/containers"
+	"github.com/containers/podman/v5/pkg/specgen"
+)
+
+// PublishPorts publishes the given TCP ports of a container created from
+// spec on random free host ports. Published ports are reachable from the host
+// with both rootful and rootless podman, unlike container addresses.
+func PublishPorts(spec *specgen.SpecGenerator, ports ...uint16) {
+	for _, port := range ports {
+		spec.PortMappings = append(spec.PortMappings, nettypes.PortMapping{
+			ContainerPort: port,
+			Protocol:      "tcp",
+		})
+	}
+}
+
+// ContainerHostPorts returns the host:port address reachable from the host
+// for each published TCP port of a running container.
+func (c *Client) ContainerHostPorts(ctx context.Context, id string) (map[uint16]string, error) {
+	data, err := bcontainers.Inspect(c.conn(ctx), id, &bcontainers.InspectOptions{})
+	if err != nil {
+		return nil, classify(err)
+	}
+	addrs := map[uint16]string{}
+	if data.NetworkSettings == nil {
+		return addrs, nil
+	}
+	for key, bindings := range data.NetworkSettings.Ports {
+		portStr, proto, _ := strings.Cut(key, "/")
+		if proto != "" && proto != "tcp" {
+			continue
+		}
+		port, err := strconv.ParseUint(portStr, 10, 16)
+		if err != nil {
+			return nil, fmt.Errorf("container %s: unexpected port %q", id, key)
+		}
+		for _, binding := range bindings {
+			if binding.HostPort == "" {
+				continue
+			}
+			host := binding.HostIP
+			if host == "" || host == "0.0.0.0" || host == "::" {
+				host = "127.0.0.1"
+			}
+			addrs[uint16(port)] = net.JoinHostPort(host, binding.HostPort)
+			break
+		}
+	}
+	return addrs, nil
+}
+
+// ContainerHostPort returns the host:port address reachable from the host for
+// one published TCP port of a running container.
+func (c *Client) ContainerHostPort(ctx context.Context, id string, port uint16) (string, error) {
+	addrs, err := c.ContainerHostPorts(ctx, id)
+	if err != nil {
+		return "", classify(err)
+	}
+	addr, ok := addrs[port]
+	if !ok {
+		return "", fmt.Errorf("container %s does not publish port %d", id, port)
+	}
+	return addr, nil
+}
@@ -0,0 +1,78 @@
+package podman
+
+import (
+	"context"
+	"io"
+	"os"
+	"time"
+
+	"github.com/containers/podman/v5/libpod/define"
+	"github.com/containers/podman/v5/pkg/domain/entities"
+	"github.com/containers/podman/v5/pkg/domain/entities/reports"
+	"github.com/containers/podman/v5/pkg/specgen"
+)
+
+// Runtime is the set of container engine operations that code driving the
+// images under test depends on. Client implements it against the podman
+// service; package fake provides an in-memory implementation for unit tests.
+//
+// ContainerList and Sweep are left out on purpose: they act on everything in
+// the engine rather than on the resources a caller created, and only belong
+// in suite setup and teardown, which always talk to the podman service.
+type Runtime interface {
+	ContainerCreate(ctx context.Context, config *specgen.SpecGenerator) (string, error)
+	ContainerStart(ctx context.Context, id string) error
+	ContainerStop(ctx context.Context, id string, timeout int) error
+	ContainerRemove(ctx context.Context, id string) ([]*reports.RmReport, error)
+	ContainerStopAndRemove(ctx context.Context, id string, timeout int) ([]*reports.RmReport, error)
+	ContainerWait(ctx context.Context, id string) (int32, error)
+	ContainerHostPort(ctx context.Context, id string, port uint16) (string, error)
+	ContainerNetworkIP(ctx context.Context, id, network string) (string, error)
+	ContainerEvents(ctx context.Context, filter *EventFilter) (<-chan ContainerEvent, error)
+	ContainerInspect(ctx context.Context, id string) (string, error)
+	ContainerHostPorts(ctx context.Context, id string) (map[uint16]string, error)
+	ContainerNetworkIPs(ctx context.Context, id string) (map[string]string, error)
+	WaitFor(ctx context.Context, id string, cond Condition) (*define.InspectContainerData, error)
+
+	ContainerStats(ctx context.Context, id string) (*Stats, error)
+	ContainerStatsSeries(ctx context.Context, id string, interval time.Duration) (StatsSeries, error)
+
+	CopyToContainer(ctx context.Context, id, src, dest string, opts *CopyOptions) error
+	WriteFileToContainer(ctx context.Context, id, name string, data []byte, mode os.FileMode, opts *CopyOptions) error
+	CopyTarToContainer(ctx context.Context, id, dest string, r io.Reader, opts *CopyOptions) error
+	CopyFromContainer(ctx context.Context, id, src, dest string) error
+	ReadFileFromContainer(ctx context.Context, id, name string) ([]byte, error)
+	CopyTarFromContainer(ctx context.Context, id, src string, w io.Writer) error
+
+	PodCreate(ctx context.Context, name string, opts *PodOptions) (string, error)
+	PodStart(ctx context.Context, nameOrID string) error
+	PodStop(ctx context.Context, nameOrID string, timeout int) error
+	PodRemove(ctx context.Context, nameOrID string) error
+	PodHostPort(ctx context.Context, nameOrID string, port uint16) (string, error)
+
+	ContainerExec(ctx context.Context, id string, cmd []string) (int, []byte, error)
+	ContainerExecWithOptions(ctx context.Context, id string, cmd []string, opts *ExecOptions) (*ExecResult, error)
+
+	ContainerLogs(ctx context.Context, id string) ([]byte, error)
+	ContainerStreamLogs(ctx context.Context, id string, opts *LogOptions, stdout, stderr io.Writer) error
+	ContainerWaitForLog(ctx context.Context, id, substr string) error
+
+	VolumeCreate(ctx context.Context, opts *VolumeOptions) (*entities.VolumeConfigResponse, error)
+	VolumeInspect(ctx context.Context, name string) (*entities.VolumeConfigResponse, error)
+	VolumeExport(ctx context.Context, name string, w io.Writer) error
+	VolumeImport(ctx context.Context, name string, r io.Reader, owner *Owner) error
+	VolumeRemove(ctx context.Context, name string) error
+
+	ImagePull(ctx context.Context, name string, policy PullPolicy) (string, error)
+	ImageTag(ctx context.Context, nameOrID, target string) error
+	ImageInspect(ctx context.Context, nameOrID string) (*ImageSummary, error)
+	ImageBuild(ctx context.Context, opts *BuildOptions) (string, error)
+	ImagesRemove(ctx context.Context, names []string) (*entities.ImageRemoveReport, error)
+
+	NetworkCreate(ctx context.Context, name string, opts *NetworkOptions) (string, error)
+	NetworkRemove(ctx context.Context, name string) error
+	NetworkConnect(ctx context.Context, network, id string, aliases ...string) error
+	NetworkDisconnect(ctx context.Context, network, id string) error
+}
+
+var _ Runtime = (*Client)(nil)
@@ -0,0 +1,81 @@
+package podman
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	nettypes "github.com/containers/common/libnetwork/types"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("SpecBuilder", func() {
+	It("should run restricted containers as an arbitrary UID in group 0", func() {
+		for i := 0; i < 100; i++ {
+			spec := NewSpec("img").Restricted().Build()
+
+			uid, gid, ok := strings.Cut(spec.User, ":")
+			Expect(ok).To(BeTrue(), "user %q has no group", spec.User)
+			Expect(gid).To(Equal("0"))
+			n, err := strconv.Atoi(uid)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(n).To(BeNumerically(">=", minRestrictedUID))
+			Expect(n).To(BeNumerically("<", maxRestrictedUID))
+
+			Expect(spec.CapDrop).To(Equal([]string{"ALL"}))
+			Expect(*spec.NoNewPrivileges).To(BeTrue())
+		}
+	})
+
+	It("should limit memory and swap to the same amount", func() {
+		spec := NewSpec("img").Memory(1 << 30).CPUs(0.5).Build()
+
+		Expect(*spec.ResourceLimits.Memory.Limit).To(Equal(int64(1 << 30)))
+		Expect(*spec.ResourceLimits.Memory.Swap).To(Equal(int64(1 << 30)))
+		Expect(*spec.ResourceLimits.CPU.Quota).To(Equal(int64(50000)))
+		Expect(*spec.ResourceLimits.CPU.Period).To(Equal(uint64(cpuPeriod)))
+	})
+
+	It("should run healthchecks with the shell", func() {
+		spec := NewSpec("img").HealthCheck(HealthCheck{
+			Command:  "curl -sf http://localhost:8080/login",
+			Interval: 10 * time.Second,
+			Retries:  3,
+		}).Build()
+
+		Expect(spec.HealthConfig.Test).To(Equal([]string{"CMD-SHELL", "curl -sf http://localhost:8080/login"}))
+		Expect(spec.HealthConfig.Interval).To(Equal(10 * time.Second))
+		Expect(spec.HealthConfig.Retries).To(Equal(3))
+	})
+
+	It("should publish ports and join networks under aliases", func() {
+		spec := NewSpec("img").
+			Ports(8080, 50000).
+			Network("ci", "jenkins", "master").
+			Network("other").
+			Build()
+
+		Expect(spec.PortMappings).To(Equal([]nettypes.PortMapping{
+			{ContainerPort: 8080, Protocol: "tcp"},
+			{ContainerPort: 50000, Protocol: "tcp"},
+		}))
+		Expect(spec.Networks).To(Equal(map[string]nettypes.PerNetworkOptions{
+			"ci":    {Aliases: []string{"jenkins", "master"}},
+			"other": {},
+		}))
+	})
+
+	It("should merge environment variables and labels", func() {
+		spec := NewSpec("img").
+			Env("A=1", "B=x=y", "EMPTY").
+			Env("A=2").
+			Labels(map[string]string{"a": "1"}).
+			Labels(map[string]string{"b": "2"}).
+			Build()
+
+		Expect(spec.Env).To(Equal(map[string]string{"A": "2", "B": "x=y", "EMPTY": ""}))
+		Expect(spec.Labels).To(Equal(map[string]string{"a": "1", "b": "2"}))
+	})
+})
@@ -0,0 +1,140 @@
+package podman
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/containers/podman/v5/libpod/define"
+	bcontainers "github.com/containers/podman/v5/pkg/bindings/containers"
+	"github.com/docker/go-units"
+)
+
+// Stats is a resource usage sample of a running container.
+type Stats struct {
+	Time time.Time
+	// CPUPercent is relative to one CPU, so it can exceed 100 on
+	// multi-core hosts.
+	CPUPercent float64
+	// MemUsage and MemLimit are in bytes; MemLimit is the host memory
+	// when the container has no limit.
+	MemUsage   uint64
+	MemLimit   uint64
+	MemPercent float64
+	PIDs       uint64
+	// BlockInput and BlockOutput are bytes read and written since start.
+	BlockInput  uint64
+	BlockOutput uint64
+}
+
+func (s Stats) String() string {
+	return fmt.Sprintf("cpu=%.1f%% mem=%s/%s (%.1f%%) pids=%d blkio=%s/%s",
+		s.CPUPercent,
+		units.BytesSize(float64(s.MemUsage)), units.BytesSize(float64(s.MemLimit)), s.MemPercent,
+		s.PIDs,
+		units.BytesSize(float64(s.BlockInput)), units.BytesSize(float64(s.BlockOutput)))
+}
+
+func newStats(s define.ContainerStats) Stats {
+	return Stats{
+		Time:        time.Now(),
+		CPUPercent:  s.CPU,
+		MemUsage:    s.MemUsage,
+		MemLimit:    s.MemLimit,
+		MemPercent:  s.MemPerc,
+		PIDs:        s.PIDs,
+		BlockInput:  s.BlockInput,
+		BlockOutput: s.BlockOutput,
+	}
+}
+
+// ContainerStats returns a single resource usage sample of a running
+// container.
+func (c *Client) ContainerStats(ctx context.Context, id string) (*Stats, error) {
+	reports, err := bcontainers.Stats(c.conn(ctx), []string{id}, new(bcontainers.StatsOptions).WithStream(false))
+	if err != nil {
+		return nil, classify(err)
+	}
+	for report := range reports {
+		if report.Error != nil {
+			return nil, report.Error
+		}
+		for _, s := range report.Stats {
+			stats := newStats(s)
+			return &stats, nil
+		}
+	}
+	return nil, fmt.Errorf("no stats reported for container %s", id)
+}
+
+// StatsSeries is a time series of samples from ContainerStatsSeries.
+type StatsSeries []Stats
+
+// MaxMemUsage returns the highest memory usage in bytes.
+func (s StatsSeries) MaxMemUsage() uint64 {
+	var max uint64
+	for _, sample := range s {
+		if sample.MemUsage > max {
+			max = sample.MemUsage
+		}
+	}
+	return max
+}
+
+// MeanCPUPercent returns the average CPU usage.
+func (s StatsSeries) MeanCPUPercent() float64 {
+	if len(s) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, sample := range s {
+		sum += sample.CPUPercent
+	}
+	return sum / float64(len(s))
+}
+
+// MaxPIDs returns the highest number of processes.
+func (s StatsSeries) MaxPIDs() uint64 {
+	var max uint64
+	for _, sample := range s {
+		if sample.PIDs > max {
+			max = sample.PIDs
+		}
+	}
+	return max
+}
+
+// ContainerStatsSeries samples the resource usage of a running container
+// every interval, rounded up to whole seconds, until ctx is done. The end of
+// ctx is the normal way to stop sampling and is not reported as an error.
+func (c *Client) ContainerStatsSeries(ctx context.Context, id string, interval time.Duration) (StatsSeries, error) {
+	seconds := int((interval + time.Second - 1) / time.Second)
+	if seconds < 1 {
+		seconds = 1
+	}
+	reports, err := bcontainers.Stats(c.conn(ctx), []string{id}, new(bcontainers.StatsOptions).WithStream(true).WithInterval(seconds))
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, nil
+		}
+		return nil, classify(err)
+	}
+
+	var series StatsSeries
+	for report := range reports {
+		if report.Error != nil {
+			// Drain so that the reader in the bindings can exit.
+			for range reports {
+			}
+			if ctx.Err() != nil || errors.Is(report.Error, context.Canceled) {
+				return series, nil
+			}
+			return series, report.Error
+		}
+		for _, s := range report.Stats {
+			series = append(series, newStats(s))
+		}
+	}
+	return series, nil
+}
@@ -0,0 +1,133 @@
+package podman
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	bcontainers "github.com/containers/podman/v5/pkg/bindings/containers"
+	bimages "github.com/containers/podman/v5/pkg/bindings/images"
+	bnetwork "github.com/containers/podman/v5/pkg/bindings/network"
+	bpods "github.com/containers/podman/v5/pkg/bindings/pods"
+	bvolumes "github.com/containers/podman/v5/pkg/bindings/volumes"
+)
+
+// SweepOptions selects the resources removed by Sweep.
+type SweepOptions struct {
+	// OlderThan is the minimum age of the run that created a resource.
+	OlderThan time.Duration
+	// ImagePrefixes selects unlabelled images, such as those built by s2i,
+	// whose repository name starts with one of the prefixes and that were
+	// created longer than OlderThan ago.
+	ImagePrefixes []string
+}
+
+// Sweep removes the containers, pods, networks, volumes and images left
+// behind by earlier test runs that started more than opts.OlderThan ago.
+// Resources of the client's own run are kept. Failures are collected and
+// returned together after everything else was removed.
+func (c *Client) Sweep(ctx context.Context, opts *SweepOptions) error {
+	if opts == nil {
+		opts = &SweepOptions{}
+	}
+	cutoff := time.Now().Add(-opts.OlderThan)
+	stale := func(labels map[string]string) bool {
+		runID, ok := labels[LabelRunID]
+		if !ok || runID == c.Labels[LabelRunID] {
+			return false
+		}
+		started, err := time.Parse(time.RFC3339, labels[LabelRunStarted])
+		return err == nil && started.Before(cutoff)
+	}
+	filters := map[string][]string{"label": {LabelRunID}}
+	var errs []error
+
+	containers, err := bcontainers.List(c.conn(ctx), new(bcontainers.ListOptions).WithAll(true).WithFilters(filters))
+	if err != nil {
+		errs = append(errs, fmt.Errorf("listing containers: %w", err))
+	}
+	for _, ctr := range containers {
+		if !stale(ctr.Labels) {
+			continue
+		}
+		rmOptions := new(bcontainers.RemoveOptions).WithForce(true).WithVolumes(true)
+		if _, err := bcontainers.Remove(c.conn(ctx), ctr.ID, rmOptions); err != nil {
+			errs = append(errs, fmt.Errorf("removing container %s: %w", ctr.ID, err))
+		}
+	}
+
+	pods, err := bpods.List(c.conn(ctx), new(bpods.ListOptions).WithFilters(filters))
+	if err != nil {
+		errs = append(errs, fmt.Errorf("listing pods: %w", err))
+	}
+	for _, pod := range pods {
+		if !stale(pod.Labels) {
+			continue
+		}
+		if err := c.PodRemove(ctx, pod.Id); err != nil {
+			errs = append(errs, fmt.Errorf("removing pod %s: %w", pod.Id, err))
+		}
+	}
+
+	networks, err := bnetwork.List(c.conn(ctx), new(bnetwork.ListOptions).WithFilters(filters))
+	if err != nil {
+		errs = append(errs, fmt.Errorf("listing networks: %w", err))
+	}
+	for _, network := range networks {
+		if !stale(network.Labels) {
+			continue
+		}
+		if err := c.NetworkRemove(ctx, network.Name); err != nil {
+			errs = append(errs, fmt.Errorf("removing network %s: %w", network.Name, err))
+		}
+	}
+
+	volumes, err := bvolumes.List(c.conn(ctx), new(bvolumes.ListOptions).WithFilters(filters))
+	if err != nil {
+		errs = append(errs, fmt.Errorf("listing volumes: %w", err))
+	}
+	for _, volume := range volumes {
+		if !stale(volume.Labels) {
+			continue
+		}
+		if err := bvolumes.Remove(c.conn(ctx), volume.Name, new(bvolumes.RemoveOptions).WithForce(true)); err != nil {
+			errs = append(errs, fmt.Errorf("removing volume %s: %w", volume.Name, err))
+		}
+	}
+
+	images, err := bimages.List(c.conn(ctx), new(bimages.ListOptions))
+	if err != nil {
+		errs = append(errs, fmt.Errorf("listing images: %w", err))
+	}
+	for _, image := range images {
+		if !stale(image.Labels) && !(hasRepoPrefix(image.RepoTags, opts.ImagePrefixes) && time.Unix(image.Created, 0).Before(cutoff)) {
+			continue
+		}
+		_, rmErrs := bimages.Remove(c.conn(ctx), []string{image.ID}, new(bimages.RemoveOptions).WithForce(true))
+		if err := errors.Join(rmErrs...); err != nil {
+			errs = append(errs, fmt.Errorf("removing image %s: %w", image.ID, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// hasRepoPrefix reports whether the last path component of the repository
+// of any of tags starts with one of prefixes.
+func hasRepoPrefix(tags, prefixes []string) bool {
+	for _, tag := range tags {
+		repo := path.Base(tag)
+		if i := strings.LastIndex(repo, ":"); i >= 0 {
+			repo = repo[:i]
+		}
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(repo, prefix) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,149 @@
+package podman
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+)
+
+const (
+	// LabelRunID identifies the test run that created a resource.
+	LabelRunID = "io.openshift.jenkins.test.run-id"
+	// LabelRunStarted holds the RFC 3339 start time of that run.
+	LabelRunStarted = "io.openshift.jenkins.test.run-started"
+)
+
+// NewRunID returns a run ID that sorts by start time and is unique across
+// hosts.
+func NewRunID(started time.Time) string {
+	b := make([]byte, 4)
+	rand.Read(b)
+	return started.UTC().Format("20060102T150405Z") + "-" + hex.EncodeToString(b)
+}
+
+// RunLabels returns the labels marking resources of the run runID started at
+// started.
+func RunLabels(runID string, started time.Time) map[string]string {
+	return map[string]string{
+		LabelRunID:      runID,
+		LabelRunStarted: started.UTC().Format(time.RFC3339),
+	}
+}
+
+// addLabels returns labels with the client's labels added, without changing
+// labels.
+func (c *Client) addLabels(labels map[string]string) map[string]string {
+	if len(c.Labels) == 0 {
+		return labels
+	}
+	merged := maps.Clone(labels)
+	if merged == nil {
+		merged = map[string]string{}
+	}
+	maps.Copy(merged, c.Labels)
+	return merged
+}
+
+// ResourceKind is the kind of a resource recorded by a Tracker.
+type ResourceKind string
+
+const (
+	ResourceContainer ResourceKind = "container"
+	ResourcePod       ResourceKind = "pod"
+	ResourceVolume    ResourceKind = "volume"
+	ResourceNetwork   ResourceKind = "network"
+	ResourceImage     ResourceKind = "image"
+)
+
+// Resource is a resource recorded by a Tracker.
+type Resource struct {
+	Kind ResourceKind
+	// ID is the ID or name the resource can be removed by.
+	ID string
+}
+
+// Tracker records the resources created by a Client so that whatever is
+// left at the end of a suite can be removed, also when a spec failed before
+// cleaning up after itself. Resources removed through the Client are
+// forgotten again.
+type Tracker struct {
+	mu        sync.Mutex
+	resources []Resource
+}
+
+// NewTracker returns an empty Tracker.
+func NewTracker() *Tracker {
+	return &Tracker{}
+}
+
+// Add records a resource. Resources created outside of the Client, such as
+// images built by s2i, can be added to have them removed by Cleanup.
+func (t *Tracker) Add(kind ResourceKind, id string) {
+	if t == nil || id == "" {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.resources = append(t.resources, Resource{Kind: kind, ID: id})
+}
+
+// Forget drops a resource that no longer exists.
+func (t *Tracker) Forget(kind ResourceKind, id string) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.resources = slices.DeleteFunc(t.resources, func(r Resource) bool {
+		return r.Kind == kind && r.ID == id
+	})
+}
+
+// Resources returns the recorded resources in the order they were created.
+func (t *Tracker) Resources() []Resource {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return slices.Clone(t.resources)
+}
+
+// Cleanup removes every recorded resource through rt, newest first, so that
+// containers go before the volumes and networks they use. Resources that are
+// already gone are skipped. A failure to remove one resource does not stop
+// the others from being removed; all failures are returned together.
+func (t *Tracker) Cleanup(ctx context.Context, rt Runtime) error {
+	resources := t.Resources()
+	var errs []error
+	for i := len(resources) - 1; i >= 0; i-- {
+		r := resources[i]
+		var err error
+		switch r.Kind {
+		case ResourceContainer:
+			_, err = rt.ContainerStopAndRemove(ctx, r.ID, 10)
+		case ResourcePod:
+			err = rt.PodRemove(ctx, r.ID)
+		case ResourceVolume:
+			err = rt.VolumeRemove(ctx, r.ID)
+		case ResourceNetwork:
+			err = rt.NetworkRemove(ctx, r.ID)
+		case ResourceImage:
+			_, err = rt.ImagesRemove(ctx, []string{r.ID})
+		default:
+			err = errors.New("unknown resource kind")
+		}
+		if err := IgnoreNotFound(err); err != nil {
+			errs = append(errs, fmt.Errorf("removing %s %s: %w", r.Kind, r.ID, err))
+			continue
+		}
+		t.Forget(r.Kind, r.ID)
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,85 @@
+package podman_test
+
+import (
+	"context"
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/openshift/jenkins/pkg/podman"
+	"github.com/openshift/jenkins/pkg/podman/fake"
+)
+
+var _ = Describe("Tracker", func() {
+	var ctx context.Context
+	var rt *fake.Runtime
+	var tracker *podman.Tracker
+	var id string
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		rt = fake.New()
+		tracker = podman.NewTracker()
+
+		_, err := rt.NetworkCreate(ctx, "net", nil)
+		Expect(err).NotTo(HaveOccurred())
+		tracker.Add(podman.ResourceNetwork, "net")
+		_, err = rt.VolumeCreate(ctx, &podman.VolumeOptions{Name: "vol"})
+		Expect(err).NotTo(HaveOccurred())
+		tracker.Add(podman.ResourceVolume, "vol")
+		id, err = rt.ContainerCreate(ctx, podman.NewSpec("img").Network("net").Build())
+		Expect(err).NotTo(HaveOccurred())
+		tracker.Add(podman.ResourceContainer, id)
+	})
+
+	// removals returns the removal calls made so far, in order.
+	removals := func() []string {
+		var methods []string
+		for _, call := range rt.Calls() {
+			switch call.Method {
+			case "ContainerStopAndRemove", "VolumeRemove", "NetworkRemove":
+				methods = append(methods, call.Method)
+			}
+		}
+		return methods
+	}
+
+	It("should remove resources newest first", func() {
+		Expect(tracker.Cleanup(ctx, rt)).To(Succeed())
+
+		Expect(removals()).To(Equal([]string{"ContainerStopAndRemove", "VolumeRemove", "NetworkRemove"}))
+		Expect(rt.Container(id)).To(BeNil())
+		Expect(tracker.Resources()).To(BeEmpty())
+	})
+
+	It("should skip resources that are already gone", func() {
+		_, err := rt.ContainerRemove(ctx, id)
+		Expect(err).NotTo(HaveOccurred())
+		tracker.Add(podman.ResourcePod, "gone")
+
+		Expect(tracker.Cleanup(ctx, rt)).To(Succeed())
+		Expect(tracker.Resources()).To(BeEmpty())
+	})
+
+	It("should keep removing after a failure and report every failure", func() {
+		errBusy := errors.New("volume is busy")
+		rt.FailNext("VolumeRemove", errBusy)
+		rt.FailNext("NetworkRemove", podman.ErrConflict)
+
+		err := tracker.Cleanup(ctx, rt)
+		Expect(err).To(MatchError(errBusy))
+		Expect(err).To(MatchError(podman.ErrConflict))
+		Expect(err).To(MatchError(ContainSubstring("removing volume vol")))
+		Expect(err).To(MatchError(ContainSubstring("removing network net")))
+
+		Expect(removals()).To(Equal([]string{"ContainerStopAndRemove", "VolumeRemove", "NetworkRemove"}))
+		Expect(tracker.Resources()).To(Equal([]podman.Resource{
+			{Kind: podman.ResourceNetwork, ID: "net"},
+			{Kind: podman.ResourceVolume, ID: "vol"},
+		}))
+
+		Expect(tracker.Cleanup(ctx, rt)).To(Succeed())
+		Expect(tracker.Resources()).To(BeEmpty())
+	})
+})
@@ -0,0 +1,136 @@
+package podman
+
+import (
+	"archive/tar"
+	"context"
+	"io"
+	"strings"
+
+	bcontainers "github.com/containers/podman/v5/pkg/bindings/containers"
+	bvolumes "github.com/containers/podman/v5/pkg/bindings/volumes"
+	"github.com/containers/podman/v5/pkg/domain/entities"
+)
+
+// DefaultHelperImage is the image of the helper containers used to access
+// volumes when Client.HelperImage is not set.
+const DefaultHelperImage = "registry.access.redhat.com/ubi9/ubi-minimal:latest"
+
+// volumeMount is where helper containers mount the volume they access.
+const volumeMount = "/volume"
+
+// VolumeOptions configures a volume created by VolumeCreate.
+type VolumeOptions struct {
+	// Name defaults to a generated one.
+	Name string
+	// Labels are applied to the volume.
+	Labels map[string]string
+	// Options are passed to the local volume driver, e.g. "type", "device"
+	// and "o" to mount a tmpfs.
+	Options map[string]string
+}
+
+// VolumeCreate creates a volume.
+func (c *Client) VolumeCreate(ctx context.Context, opts *VolumeOptions) (*entities.VolumeConfigResponse, error) {
+	if opts == nil {
+		opts = &VolumeOptions{}
+	}
+	config := entities.VolumeCreateOptions{
+		Name:    opts.Name,
+		Labels:  c.addLabels(opts.Labels),
+		Options: opts.Options,
+	}
+	resp, err := bvolumes.Create(c.conn(ctx), config, &bvolumes.CreateOptions{})
+	if err != nil {
+		return nil, classify(err)
+	}
+	c.Tracker.Add(ResourceVolume, resp.Name)
+	return resp, nil
+}
+
+// VolumeInspect returns the configuration of a volume. Its Mountpoint is a
+// path on the host running the podman service.
+func (c *Client) VolumeInspect(ctx context.Context, name string) (*entities.VolumeConfigResponse, error) {
+	resp, err := bvolumes.Inspect(c.conn(ctx), name, &bvolumes.InspectOptions{})
+	return resp, classify(err)
+}
+
+// VolumeExport writes the contents of a volume to w as a tar stream with
+// entries relative to the volume's root. Ownership and modes are kept, so
+// the stream can be imported again with VolumeImport.
+func (c *Client) VolumeExport(ctx context.Context, name string, w io.Writer) error {
+	return c.withVolume(ctx, name, func(id string) error {
+		pr, pw := io.Pipe()
+		go func() {
+			pw.CloseWithError(c.CopyTarFromContainer(ctx, id, volumeMount, pw))
+		}()
+		err := stripTarRoot(pr, w, strings.TrimPrefix(volumeMount, "/"))
+		pr.CloseWithError(err)
+		return classify(err)
+	})
+}
+
+// VolumeImport extracts the tar stream r, as written by VolumeExport, into
+// a volume, replacing existing files with the same names. Entries keep the
+// ownership recorded in the stream unless owner is set.
+func (c *Client) VolumeImport(ctx context.Context, name string, r io.Reader, owner *Owner) error {
+	if owner != nil {
+		r = chownTar(r, *owner)
+	}
+	return c.withVolume(ctx, name, func(id string) error {
+		copyOptions := new(bcontainers.CopyOptions).WithChown(false)
+		copyFunc, err := bcontainers.CopyFromArchiveWithOptions(c.conn(ctx), id, volumeMount, r, copyOptions)
+		if err != nil {
+			return classify(err)
+		}
+		return copyFunc()
+	})
+}
+
+// withVolume calls fn with the ID of a helper container that has the volume
+// name mounted at volumeMount. The container is never started.
+func (c *Client) withVolume(ctx context.Context, name string, fn func(id string) error) error {
+	image := c.HelperImage
+	if image == "" {
+		image = DefaultHelperImage
+	}
+	if _, err := c.ImagePull(ctx, image, PullMissing); err != nil {
+		return classify(err)
+	}
+	spec := NewSpec(image).Entrypoint("/bin/true").Volume(name, volumeMount).Build()
+	id, err := c.ContainerCreate(ctx, spec)
+	if err != nil {
+		return classify(err)
+	}
+	err = fn(id)
+	if _, rmErr := c.ContainerRemove(context.WithoutCancel(ctx), id); err == nil {
+		err = rmErr
+	}
+	return classify(err)
+}
+
+// stripTarRoot copies the tar stream r to w, dropping the directory root
+// and making the names of the entries below it relative to it.
+func stripTarRoot(r io.Reader, w io.Writer, root string) error {
+	tr := tar.NewReader(r)
+	tw := tar.NewWriter(w)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return tw.Close()
+		}
+		if err != nil {
+			return err
+		}
+		rel, ok := strings.CutPrefix(strings.TrimPrefix(hdr.Name, "./"), root+"/")
+		if !ok || rel == "" {
+			continue
+		}
+		hdr.Name = rel
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if _, err := io.Copy(tw, tr); err != nil {
+			return err
+		}
+	}
+}
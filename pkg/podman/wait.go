@@ -0,0 +1,132 @@
+package podman
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/containers/image/v5/manifest"
+	"github.com/containers/podman/v5/libpod/define"
+	bcontainers "github.com/containers/podman/v5/pkg/bindings/containers"
+	"github.com/containers/podman/v5/pkg/specgen"
+)
+
+// HealthCheck describes a command podman runs periodically to decide whether
+// a container is healthy.
+type HealthCheck struct {
+	// Command is run with the container's shell, e.g.
+	// "curl -sf http://localhost:8080/login".
+	Command string
+	// Interval between checks, Timeout of a single check, and StartPeriod
+	// during which failures do not count. Zero values use podman's defaults.
+	Interval    time.Duration
+	Timeout     time.Duration
+	StartPeriod time.Duration
+	// Retries is the number of consecutive failures that make the
+	// container unhealthy.
+	Retries int
+}
+
+// SetHealthCheck attaches hc to a container created from spec.
+func SetHealthCheck(spec *specgen.SpecGenerator, hc HealthCheck) {
+	spec.HealthConfig = &manifest.Schema2HealthConfig{
+		Test:        []string{"CMD-SHELL", hc.Command},
+		Interval:    hc.Interval,
+		Timeout:     hc.Timeout,
+		StartPeriod: hc.StartPeriod,
+		Retries:     hc.Retries,
+	}
+}
+
+// Condition is a state of a container that WaitFor waits for.
+type Condition struct {
+	desc  string
+	check func(*define.InspectContainerData) (bool, error)
+	// health makes WaitFor run the healthcheck on every poll, as podman
+	// only schedules healthchecks itself when systemd is available.
+	health bool
+}
+
+// ConditionFunc returns a Condition that is met once fn returns true. fn
+// may return an error to stop waiting early.
+func ConditionFunc(desc string, fn func(*define.InspectContainerData) (bool, error)) Condition {
+	return Condition{desc: desc, check: fn}
+}
+
+// Met reports whether data meets the condition. It lets other Runtime
+// implementations evaluate conditions.
+func (c Condition) Met(data *define.InspectContainerData) (bool, error) {
+	return c.check(data)
+}
+
+func (c Condition) String() string {
+	return c.desc
+}
+
+var (
+	// ConditionRunning is met once the container is running. Waiting for it
+	// fails if the container exits first.
+	ConditionRunning = Condition{desc: "running", check: func(data *define.InspectContainerData) (bool, error) {
+		if data.State.Running {
+			return true, nil
+		}
+		return false, exitedError(data)
+	}}
+
+	// ConditionExited is met once the container has stopped.
+	ConditionExited = Condition{desc: "exited", check: func(data *define.InspectContainerData) (bool, error) {
+		return hasExited(data), nil
+	}}
+
+	// ConditionHealthy is met once the container's healthcheck passes.
+	// Waiting for it fails if the container exits first.
+	ConditionHealthy = Condition{desc: "healthy", health: true, check: func(data *define.InspectContainerData) (bool, error) {
+		if data.State.Health != nil && data.State.Health.Status == define.HealthCheckHealthy {
+			return true, nil
+		}
+		return false, exitedError(data)
+	}}
+)
+
+func hasExited(data *define.InspectContainerData) bool {
+	return data.State.Status == "exited" || data.State.Status == "stopped"
+}
+
+func exitedError(data *define.InspectContainerData) error {
+	if hasExited(data) {
+		return fmt.Errorf("container %s exited with code %d", data.ID, data.State.ExitCode)
+	}
+	return nil
+}
+
+// WaitFor polls a container until cond is met and returns the inspect data
+// that met it.
+func (c *Client) WaitFor(ctx context.Context, id string, cond Condition) (*define.InspectContainerData, error) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		if cond.health {
+			// Failures are recorded in the health status; only the
+			// inspect below decides.
+			_, _ = bcontainers.RunHealthCheck(c.conn(ctx), id, &bcontainers.HealthCheckOptions{})
+		}
+		data, err := bcontainers.Inspect(c.conn(ctx), id, &bcontainers.InspectOptions{})
+		if err != nil {
+			return nil, classify(err)
+		}
+		done, err := cond.check(data)
+		if err != nil {
+			return data, classify(err)
+		}
+		if done {
+			return data, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return data, fmt.Errorf("waiting for container %s to be %s: %w", id, cond.desc, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
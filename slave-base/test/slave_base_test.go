@@ -1,10 +1,13 @@
 package test
 
 import (
+	"context"
+	"fmt"
 	"os"
+	"strings"
 	"testing"
+	"time"
 
-	"github.com/containers/podman/v5/pkg/specgen"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
@@ -17,54 +20,177 @@ func Test(t *testing.T) {
 }
 
 var podmancli *podman.Client
+var diagnostics *podman.Diagnostics
 var imageName string
 
 var _ = BeforeSuite(func() {
 	var err error
 	podmancli, err = podman.NewEnvClient()
 	Expect(err).NotTo(HaveOccurred())
+	diagnostics = &podman.Diagnostics{
+		Client:  podmancli,
+		Labels:  map[string]string{podman.LabelRunID: podmancli.Labels[podman.LabelRunID]},
+		Dir:     os.Getenv("ARTIFACT_DIR"),
+		Tarball: true,
+		Paths:   []string{},
+	}
 
 	imageName = os.Getenv("IMAGE_NAME")
 	if imageName == "" {
 		imageName = "openshift/jenkins-slave-base-centos7-candidate"
 	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	err = podmancli.Sweep(ctx, &podman.SweepOptions{OlderThan: 2 * time.Hour})
+	if err != nil {
+		fmt.Fprintf(GinkgoWriter, "removing resources of earlier runs: %v\n", err)
+	}
+
+	_, err = podmancli.ImagePull(ctx, imageName, podman.PullMissing)
+	Expect(err).NotTo(HaveOccurred())
+})
+
+var _ = AfterSuite(func() {
+	if podmancli == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+	err := podmancli.Tracker.Cleanup(ctx, podmancli)
+	Expect(err).NotTo(HaveOccurred())
+})
+
+var _ = Describe("Base slave image", func() {
+	It("should run the JNLP client through go-init", func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+
+		image, err := podmancli.ImageInspect(ctx, imageName)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(image.Entrypoint).To(Equal([]string{"/usr/bin/go-init", "-main", "/usr/local/bin/run-jnlp-client"}))
+	})
 })
 
 var _ = Describe("Base slave testing", func() {
 	var id string
+	var ctx context.Context
+	var cancel context.CancelFunc
+
+	BeforeEach(func() {
+		ctx, cancel = context.WithTimeout(context.Background(), 10*time.Minute)
+	})
 
 	AfterEach(func() {
+		cancel()
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+		defer cancel()
+
 		if CurrentGinkgoTestDescription().Failed {
+			By("collecting diagnostics")
+			if path, err := diagnostics.Collect(ctx); err != nil {
+				fmt.Fprintf(GinkgoWriter, "collecting diagnostics: %v\n", err)
+			} else {
+				fmt.Fprintf(GinkgoWriter, "diagnostics bundle: %s\n", path)
+			}
+
 			By("printing container logs")
-			logs, err := podmancli.ContainerLogs(id)
-			Expect(err).NotTo(HaveOccurred())
-			_, err = GinkgoWriter.Write(logs)
+			err := podmancli.ContainerStreamLogs(ctx, id, nil, GinkgoWriter, GinkgoWriter)
 			Expect(err).NotTo(HaveOccurred())
 		}
 
-		err := podmancli.ContainerStop(id, 60)
+		_, err := podmancli.ContainerStopAndRemove(ctx, id, 60)
+		Expect(podman.IgnoreNotFound(err)).To(Succeed())
+	})
+
+	It("should contain a runnable oc", func() {
+		var err error
+		spec := podman.NewSpec(imageName).
+			Terminal().
+			Entrypoint("/bin/bash", "-l", "-c").
+			Command("oc").
+			Build()
+		id, err = podmancli.ContainerCreate(ctx, spec)
 		Expect(err).NotTo(HaveOccurred())
 
-		_, err = podmancli.ContainerRemove(id)
+		err = podmancli.ContainerStart(ctx, id)
 		Expect(err).NotTo(HaveOccurred())
 
+		data, err := podmancli.WaitFor(ctx, id, podman.ConditionExited)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(data.State.ExitCode).To(Equal(int32(0)))
 	})
+})
 
-	It("should contain a runnable oc", func() {
-		var err error
-		sgen := specgen.NewSpecGenerator(imageName, false)
-		var terminal = true
-		sgen.Terminal = &terminal
-		sgen.Command = []string{"oc"}
-		sgen.Entrypoint = []string{"/bin/bash", "-l", "-c"}
-		id, err = podmancli.ContainerCreate(sgen)
+var _ = Describe("Base slave pod", func() {
+	var pod string
+	var ctx context.Context
+	var cancel context.CancelFunc
+
+	BeforeEach(func() {
+		pod = ""
+		ctx, cancel = context.WithTimeout(context.Background(), 10*time.Minute)
+	})
+
+	AfterEach(func() {
+		cancel()
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+		defer cancel()
+
+		if CurrentGinkgoTestDescription().Failed {
+			By("collecting diagnostics")
+			if path, err := diagnostics.Collect(ctx); err != nil {
+				fmt.Fprintf(GinkgoWriter, "collecting diagnostics: %v\n", err)
+			} else {
+				fmt.Fprintf(GinkgoWriter, "diagnostics bundle: %s\n", path)
+			}
+		}
+
+		if pod != "" {
+			err := podmancli.PodRemove(ctx, pod)
+			Expect(podman.IgnoreNotFound(err)).To(Succeed())
+		}
+	})
+
+	// container creates a container in the pod that waits on its terminal,
+	// like the containers of the java-builder pod template.
+	container := func(name string) string {
+		spec := podman.NewSpec(imageName).
+			Name(name).
+			Terminal().
+			Entrypoint("cat").
+			Pod(pod).
+			Build()
+		id, err := podmancli.ContainerCreate(ctx, spec)
+		Expect(err).NotTo(HaveOccurred())
+		return id
+	}
+
+	namespace := func(id, ns string) string {
+		code, out, err := podmancli.ContainerExec(ctx, id, []string{"readlink", "/proc/self/ns/" + ns})
 		Expect(err).NotTo(HaveOccurred())
+		Expect(code).To(Equal(0))
+		return strings.TrimSpace(string(out))
+	}
 
-		err = podmancli.ContainerStart(id)
+	It("should share network and IPC namespaces with a builder container", func() {
+		var err error
+		pod, err = podmancli.PodCreate(ctx, "", nil)
 		Expect(err).NotTo(HaveOccurred())
 
-		code, err := podmancli.ContainerWait(id)
+		jnlp := container(fmt.Sprintf("jnlp-%s", pod[:12]))
+		java := container(fmt.Sprintf("java-%s", pod[:12]))
+
+		err = podmancli.PodStart(ctx, pod)
 		Expect(err).NotTo(HaveOccurred())
-		Expect(code).To(Equal(int32(0)))
+
+		for _, id := range []string{jnlp, java} {
+			_, err = podmancli.WaitFor(ctx, id, podman.ConditionRunning)
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		Expect(namespace(java, "net")).To(Equal(namespace(jnlp, "net")))
+		Expect(namespace(java, "ipc")).To(Equal(namespace(jnlp, "ipc")))
 	})
 })